@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore/postgres"
+	"github.com/rh-mithu/rizon/backend/migrations"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+type Migrate struct {
+	cfg *config.Config
+	l   *slog.Logger
+}
+
+func ProvideMigrate(cfg *config.Config, l *slog.Logger) *Migrate {
+	return &Migrate{
+		cfg: cfg,
+		l:   l,
+	}
+}
+
+func (m *Migrate) RunMigrateCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:     "migrate",
+		Short:   "Manage database migrations",
+		Long:    "Apply, roll back and inspect database migrations",
+		Example: "[binary] migrate up",
+	}
+
+	command.AddCommand(
+		m.upCommand(),
+		m.downCommand(),
+		m.statusCommand(),
+		m.createCommand(),
+		m.lockCommand(),
+		m.unlockCommand(),
+	)
+	return command
+}
+
+func (m *Migrate) upCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "up",
+		Short:   "Apply pending migrations",
+		Example: "[binary] migrate up",
+		Args:    cobra.NoArgs,
+		RunE: m.withMigrator(func(ctx context.Context, cmd *cobra.Command, mg *postgres.Migrator, args []string) error {
+			_, err := mg.Up(ctx)
+			return err
+		}),
+	}
+}
+
+func (m *Migrate) downCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "down",
+		Short:   "Roll back the last migration group",
+		Example: "[binary] migrate down",
+		Args:    cobra.NoArgs,
+		RunE: m.withMigrator(func(ctx context.Context, cmd *cobra.Command, mg *postgres.Migrator, args []string) error {
+			_, err := mg.Down(ctx)
+			return err
+		}),
+	}
+}
+
+func (m *Migrate) statusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "status",
+		Short:   "List migrations and whether they are applied",
+		Example: "[binary] migrate status",
+		Args:    cobra.NoArgs,
+		RunE: m.withMigrator(func(ctx context.Context, cmd *cobra.Command, mg *postgres.Migrator, args []string) error {
+			ms, err := mg.Status(ctx)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "MIGRATION\tGROUP\tMIGRATED AT")
+			for _, mig := range ms {
+				if mig.IsApplied() {
+					fmt.Fprintf(w, "%s\t%d\t%s\n", mig, mig.GroupID, mig.MigratedAt.Format("2006-01-02 15:04:05"))
+				} else {
+					fmt.Fprintf(w, "%s\t-\tpending\n", mig)
+				}
+			}
+			return w.Flush()
+		}),
+	}
+}
+
+func (m *Migrate) createCommand() *cobra.Command {
+	var goMigration, tx bool
+	command := &cobra.Command{
+		Use:     "create <name>",
+		Short:   "Create up and down migration files",
+		Example: "[binary] migrate create add_user_roles",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Creating files only needs the migrations directory, not a connection
+			mg := postgres.NewMigratorForFiles(migrations.Migrations)
+			if goMigration {
+				f, err := mg.CreateGo(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "created %s\n", f.Path)
+				return nil
+			}
+			files, err := mg.CreateSQL(cmd.Context(), args[0], tx)
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				fmt.Fprintf(cmd.OutOrStdout(), "created %s\n", f.Path)
+			}
+			return nil
+		},
+	}
+	command.Flags().BoolVar(&goMigration, "go", false, "create a Go migration instead of SQL files")
+	command.Flags().BoolVar(&tx, "tx", false, "run the SQL migration inside a transaction")
+	return command
+}
+
+func (m *Migrate) lockCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "lock",
+		Short:   "Block migrations until unlocked",
+		Example: "[binary] migrate lock",
+		Args:    cobra.NoArgs,
+		RunE: m.withMigrator(func(ctx context.Context, cmd *cobra.Command, mg *postgres.Migrator, args []string) error {
+			return mg.Lock(ctx)
+		}),
+	}
+}
+
+func (m *Migrate) unlockCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "unlock",
+		Short:   "Allow migrations again",
+		Example: "[binary] migrate unlock",
+		Args:    cobra.NoArgs,
+		RunE: m.withMigrator(func(ctx context.Context, cmd *cobra.Command, mg *postgres.Migrator, args []string) error {
+			return mg.Unlock(ctx)
+		}),
+	}
+}
+
+// withMigrator opens a migrator for the duration of the command and cancels it on SIGINT/SIGTERM
+func (m *Migrate) withMigrator(
+	fn func(ctx context.Context, cmd *cobra.Command, mg *postgres.Migrator, args []string) error,
+) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		mg := postgres.NewMigrator(m.cfg, m.l, migrations.Migrations)
+		defer mg.Close()
+		return fn(ctx, cmd, mg, args)
+	}
+}
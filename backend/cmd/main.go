@@ -33,6 +33,7 @@ func main() {
 	}
 	cmd := commands.ProvideServer(cfg, l)
 	rootCmd.AddCommand(cmd.RunServerCommand())
+	rootCmd.AddCommand(commands.ProvideMigrate(cfg, l).RunMigrateCommand())
 	if err := rootCmd.Execute(); err != nil {
 		slog.Error("Command executed with error", "cause", err)
 		os.Exit(1)
@@ -4,6 +4,7 @@ import (
 	"github.com/caarlos0/env/v10"
 	"github.com/joho/godotenv"
 	"log"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +12,51 @@ type Config struct {
 	ServicePort    string `env:"SERVICE_PORT" envDefault:"8080"`
 	SQLDatabaseURL string `env:"SQL_DATABASE_URL,required"`
 	JWTSecret      string `env:"JWT_SECRET,file,required"`
+
+	// Apply pending migrations before serving; replicas wait on an advisory lock
+	MigrateOnStart bool `env:"MIGRATE_ON_START" envDefault:"false"`
+
+	// Take the client address from X-Forwarded-For / X-Real-IP; only enable
+	// behind a proxy that overwrites them
+	TrustProxyHeaders bool `env:"TRUST_PROXY_HEADERS" envDefault:"false"`
+
+	// Retries of transactions aborted by serialization failures or deadlocks
+	TxMaxAttempts    int           `env:"TX_MAX_ATTEMPTS" envDefault:"3"`
+	TxRetryBaseDelay time.Duration `env:"TX_RETRY_BASE_DELAY" envDefault:"20ms"`
+	TxRetryMaxDelay  time.Duration `env:"TX_RETRY_MAX_DELAY" envDefault:"500ms"`
+
+	// Signs datastore pagination cursors; derived from JWTSecret when empty
+	CursorSecret string `env:"CURSOR_SECRET,file"`
+
+	// Access token verification. HS* algorithms use JWTSecret; RS*, PS*, ES* and EdDSA
+	// use the JWKS document from JWKSFile or JWKSURL.
+	JWTAlgorithms []string      `env:"JWT_ALGORITHMS" envSeparator:"," envDefault:"HS256"`
+	JWKSFile      string        `env:"JWKS_FILE"`
+	JWKSURL       string        `env:"JWKS_URL"`
+	JWKSCacheTTL  time.Duration `env:"JWKS_CACHE_TTL" envDefault:"10m"`
+
+	// Access token claim validation
+	JWTIssuer         string        `env:"JWT_ISSUER"`
+	JWTAudience       []string      `env:"JWT_AUDIENCE" envSeparator:","`
+	JWTLeeway         time.Duration `env:"JWT_LEEWAY" envDefault:"30s"`
+	JWTRequiredClaims []string      `env:"JWT_REQUIRED_CLAIMS" envSeparator:"," envDefault:"exp,sub"`
+
+	// Passwordless login
+	MagicLinkURL    string        `env:"MAGIC_LINK_URL" envDefault:"rizon://auth/verify"`
+	MagicLinkTTL    time.Duration `env:"MAGIC_LINK_TTL" envDefault:"15m"`
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+
+	// Sessions and revocation
+	SessionTTL       time.Duration `env:"SESSION_TTL" envDefault:"720h"`
+	SessionCacheSize int           `env:"SESSION_CACHE_SIZE" envDefault:"10000"`
+	SessionCacheTTL  time.Duration `env:"SESSION_CACHE_TTL" envDefault:"30s"`
+
+	// Mail delivery: "log" writes to the logger, "file" appends to MailFilePath.
+	// Both are for local development and refused when Env is "production".
+	MailDriver   string `env:"MAIL_DRIVER" envDefault:"log"`
+	MailFrom     string `env:"MAIL_FROM" envDefault:"no-reply@rizon.local"`
+	MailFilePath string `env:"MAIL_FILE_PATH" envDefault:"mail.log"`
 }
 
 func Load() (*Config, error) {
@@ -0,0 +1,91 @@
+package datastore
+
+// Pipeline is a database-neutral aggregation: each stage works on the rows
+// produced by the previous one, as in
+//
+//	Pipeline{
+//		MatchStage{"status": "paid"},
+//		GroupStage{
+//			By:     []GroupKey{{Field: "created_at", As: "day", Bucket: BucketDay}},
+//			Fields: []Accumulator{{As: "revenue", Op: AggSum, Field: "amount"}, {As: "orders", Op: AggCount}},
+//		},
+//		SortStage{{Field: "day", Order: Desc}},
+//		LimitStage(30),
+//	}
+type Pipeline []Stage
+
+// Stage is one step of a Pipeline: MatchStage, GroupStage, ProjectStage, SortStage or LimitStage
+type Stage interface {
+	stage()
+}
+
+// MatchStage keeps the rows matching a filter written like QueryOption.Filter
+type MatchStage map[string]interface{}
+
+// GroupStage collapses rows sharing the By keys into one row holding the keys and the Fields
+type GroupStage struct {
+	By     []GroupKey
+	Fields []Accumulator
+}
+
+// GroupKey groups by Field, optionally truncated to a date Bucket, and names the result As
+type GroupKey struct {
+	Field  string
+	As     string // defaults to Field
+	Bucket DateBucket
+}
+
+// Accumulator computes Op over Field for each group; Field may be empty for AggCount
+type Accumulator struct {
+	As       string
+	Op       AggOp
+	Field    string
+	Distinct bool
+}
+
+// ProjectStage reshapes rows to the listed fields
+type ProjectStage []Projection
+
+// Projection selects Field, optionally truncated to a date Bucket, as As
+type Projection struct {
+	Field  string
+	As     string // defaults to Field
+	Bucket DateBucket
+}
+
+// SortStage orders rows; Field refers to the columns produced by earlier stages.
+// It must be the last stage but for any LimitStage.
+type SortStage []SortField
+
+// LimitStage keeps the first n rows
+type LimitStage int64
+
+func (MatchStage) stage()   {}
+func (GroupStage) stage()   {}
+func (ProjectStage) stage() {}
+func (SortStage) stage()    {}
+func (LimitStage) stage()   {}
+
+// AggOp is an accumulator function of a GroupStage
+type AggOp string
+
+const (
+	AggSum   AggOp = "sum"
+	AggAvg   AggOp = "avg"
+	AggMin   AggOp = "min"
+	AggMax   AggOp = "max"
+	AggCount AggOp = "count"
+)
+
+// DateBucket truncates a timestamp to the start of its period
+type DateBucket string
+
+const (
+	BucketMinute  DateBucket = "minute"
+	BucketHour    DateBucket = "hour"
+	BucketDay     DateBucket = "day"
+	BucketWeek    DateBucket = "week"
+	BucketMonth   DateBucket = "month"
+	BucketQuarter DateBucket = "quarter"
+	BucketYear    DateBucket = "year"
+)
@@ -2,8 +2,14 @@ package datastore
 
 import (
 	"context"
+	"errors"
+	"iter"
 )
 
+// ErrInvalidCursor is returned by FindPage for a cursor that is malformed, was
+// not signed by this store or was issued for a different sort.
+var ErrInvalidCursor = errors.New("datastore: invalid cursor")
+
 // SortOrder defines sorting direction
 type SortOrder string
 
@@ -12,42 +18,179 @@ const (
 	Desc SortOrder = "desc"
 )
 
+// NullsOrder places NULL values before or after the others; empty keeps the database default
+type NullsOrder string
+
+const (
+	NullsFirst NullsOrder = "first"
+	NullsLast  NullsOrder = "last"
+)
+
+// SortField is one entry of an ordered sort. Exactly one of Field or Expr is set:
+// Field is a column, optionally qualified with a joined alias ("o.amount");
+// Expr is a raw SQL expression whose ? placeholders are bound from Args.
+type SortField struct {
+	Field string
+	Expr  string
+	Args  []interface{}
+	Order SortOrder
+	Nulls NullsOrder
+}
+
+// Logical filter groups. The value of FilterAnd and FilterOr is a
+// []map[string]interface{}; the value of FilterNot is a map[string]interface{}.
+const (
+	FilterAnd = "$and"
+	FilterOr  = "$or"
+	FilterNot = "$not"
+)
+
 // QueryOption defines filtering, sorting, pagination, joins, and aggregation
 type QueryOption struct {
-	Filter map[string]interface{} // e.g. {"age >": 30, "status": "active"}
-	Sort   map[string]SortOrder   // e.g. {"created_at": Desc}
-	Limit  int64
-	Skip   int64
-	Select []string // projection fields (e.g. ["id","name"])
+	// Filter keys are columns, optionally with an operator as a "__op" suffix
+	// (eq, ne, gt, gte, lt, lte, like, ilike, in, not_in, between, contains,
+	// is_null, is_not_null) or after a space ("age >", "status in"), plus the
+	// FilterAnd/FilterOr/FilterNot groups.
+	// e.g. {"age >": 30, "status": "active", "$or": []map[string]interface{}{{"vip": true}, {"score__gte": 90}}}
+	Filter map[string]interface{}
+	// OrderBy is applied in sequence and followed by the primary key as a tiebreaker.
+	// e.g. []SortField{{Field: "created_at", Order: Desc, Nulls: NullsLast}}
+	OrderBy []SortField
+	// Deprecated: map iteration has no order, so keys are applied alphabetically
+	// after OrderBy. Use OrderBy instead.
+	Sort  map[string]SortOrder
+	Limit int64
+	Skip  int64
+	// Cursor continues FindPage from a Page.Next or Page.Prev cursor; it replaces Skip.
+	Cursor string
+	// WithTotal makes FindPage also count every row matching Filter
+	WithTotal bool
+	Select    []string // projection fields (e.g. ["id","name"])
 
 	// Advanced relational options
 	Join      []JoinOption      // e.g. {Type: "INNER", Table: "orders o", On: "u.id = o.user_id"}
 	Group     []string          // e.g. {"u.id"}
-	Having    map[string]string // e.g. {"SUM(o.amount) >": "100"}
+	Having    map[string]string // aggregate or column plus operator, value is bound: {"SUM(o.amount) >": "100"}
 	Distinct  bool
 	Relations []string
 }
 
 type JoinOption struct {
-	Type   string   // LEFT, INNER, etc.
-	Table  string   // e.g. "associations a"
-	Alias  string   // e.g. "a"
-	On     string   // join condition
-	Select []string // columns to fetch from join
+	Type   string        // INNER (default), LEFT, RIGHT, FULL (each optionally OUTER) or CROSS
+	Table  string        // e.g. "associations" or "associations a"
+	Alias  string        // e.g. "a"
+	On     string        // join condition, may contain ? placeholders bound from Args
+	Args   []interface{} // values for the placeholders in On
+	Select []string      // columns to fetch from join, e.g. "amount" or "amount AS order_amount"
+}
+
+// WriteResult reports the outcome of a write. Insert, Update and Upsert also
+// scan the written row back into the model through RETURNING, so generated
+// IDs, defaults and timestamps are filled in.
+//
+// A model field tagged with the "version" bun option, e.g.
+//
+//	Version int64 `bun:"version,notnull,default:1,version"`
+//
+// enables optimistic concurrency: Update only matches the row while it still
+// holds the model's version, increments it, and fails with ErrConflict when
+// nothing matched.
+type WriteResult struct {
+	RowsAffected int64
+}
+
+// BulkOption tunes InsertMany
+type BulkOption struct {
+	// ChunkSize is the number of rows per INSERT, 1000 when zero. It is lowered
+	// for wide models so a chunk stays within Postgres' 65535 bind parameters.
+	ChunkSize int
+}
+
+// UpsertOption controls how Upsert resolves a conflict with an existing row.
+// Exactly one of Conflict or Constraint is required unless DoNothing is set.
+type UpsertOption struct {
+	Conflict   []string // conflict target columns, in the order of the unique index
+	Constraint string   // or the name of a unique or exclusion constraint
+	DoNothing  bool     // keep the existing row untouched
+	// Update lists the columns overwritten with the new values; nil updates
+	// every column except the conflict target and the primary key.
+	Update []string
+	// Where only updates existing rows matching this filter (QueryOption.Filter syntax)
+	Where map[string]interface{}
+	// Returning scans the inserted or updated row back into data. When nothing
+	// was written (DoNothing, or Where did not match) data is left as is.
+	Returning bool
 }
 
-// Transaction interface for databases that support transactions
+// Page describes a keyset page returned by FindPage. The cursors are opaque and
+// signed; pass one back as QueryOption.Cursor with the same Filter and sort.
+type Page struct {
+	Next  string // empty on the last page
+	Prev  string // empty on the first page
+	Total *int   // set when QueryOption.WithTotal is true
+}
+
+// Transaction interface for databases that support transactions. DataStore
+// calls made with Context() run inside the transaction.
 type Transaction interface {
+	Context() context.Context
 	Commit(ctx context.Context) error
 	Rollback(ctx context.Context) error
 }
 
+// Rows is a forward-only iterator over a streamed result set. The rows are
+// fetched in batches inside the transaction carried by the context, or a
+// read-only one started for the stream and ended by Close, which must always
+// be called.
+type Rows interface {
+	Next() bool
+	Scan(dest interface{}) error
+	Err() error
+	Close() error
+}
+
+// IsolationLevel of a transaction; empty uses the database default
+type IsolationLevel string
+
+const (
+	ReadCommitted  IsolationLevel = "read committed"
+	RepeatableRead IsolationLevel = "repeatable read"
+	Serializable   IsolationLevel = "serializable"
+)
+
+// TxOptions configures a transaction. A nested transaction runs as a savepoint
+// of the outer one and cannot change these.
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool
+	// Retry overrides the store's retry policy for RunInTransaction
+	Retry *RetryPolicy
+}
+
 // Index definition for NoSQL or relational indexing
 type Index struct {
+	// Fields lists the indexed columns in order, e.g. {{Column: "email"}, {Column: "created_at", Order: Desc}}
+	Fields []IndexField
+	// Deprecated: map iteration has no order, so keys are indexed alphabetically
+	// after Fields. Use Fields instead.
 	Keys   map[string]int // e.g. {"email": 1, "created_at": -1}
 	Unique bool
-	Sparse bool
-	Name   string
+	Sparse bool   // only index rows where every column is non-null
+	Name   string // generated from the table and columns when empty
+}
+
+// IndexField is one column of an Index
+type IndexField struct {
+	Column string
+	Order  SortOrder
+}
+
+// IndexReport lists the indexes, by name, that EnsureIndices or DropIndices touched
+type IndexReport struct {
+	Created   []string
+	Recreated []string // existed with a different definition or were left invalid by a failed build
+	Dropped   []string
+	Unchanged []string
 }
 
 // DataStore — universal database interface
@@ -55,25 +198,40 @@ type DataStore interface {
 	Ping(ctx context.Context) error
 	Disconnect(ctx context.Context) error
 	GetDatabase() interface{}
-	Insert(ctx context.Context, table string, data interface{}) error
-	InsertMany(ctx context.Context, table string, data []interface{}) error
-	Update(ctx context.Context, filter map[string]interface{}, data interface{}) error
-	UpdateMany(ctx context.Context, filter map[string]interface{}, data interface{}) error
-	Upsert(ctx context.Context, table string, filter map[string]interface{}, data interface{}) error
-	Delete(ctx context.Context, table string, filter map[string]interface{}) error
-	DeleteMany(ctx context.Context, table string, filter map[string]interface{}) error
+	Insert(ctx context.Context, table string, data interface{}) (*WriteResult, error)
+	// InsertMany inserts a slice of models in chunks of multi-row INSERTs
+	InsertMany(ctx context.Context, table string, data interface{}, opts *BulkOption) (*WriteResult, error)
+	// CopyFrom streams rows into columns of table with COPY FROM STDIN. Rows are
+	// consumed as they are sent, so the load runs in a single transaction that
+	// is never retried.
+	CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq2[[]interface{}, error]) (*WriteResult, error)
+	Update(ctx context.Context, table string, filter map[string]interface{}, data interface{}) (*WriteResult, error)
+	UpdateMany(ctx context.Context, table string, filter map[string]interface{}, data interface{}) (*WriteResult, error)
+	Upsert(ctx context.Context, table string, data interface{}, opts *UpsertOption) (*WriteResult, error)
+	Delete(ctx context.Context, table string, filter map[string]interface{}) (*WriteResult, error)
+	DeleteMany(ctx context.Context, table string, filter map[string]interface{}) (*WriteResult, error)
 
 	FindOne(ctx context.Context, table, alias string, dest interface{}, opts *QueryOption) error
 	FindMany(ctx context.Context, table, alias string, dest interface{}, opts *QueryOption) error
+	FindPage(ctx context.Context, table, alias string, dest interface{}, opts *QueryOption) (*Page, error)
 	Count(ctx context.Context, table, alias string, dest interface{}, opts *QueryOption) (int, error)
 	Distinct(ctx context.Context, table, field string, filter map[string]interface{}, dest interface{}) error
 
-	Aggregate(ctx context.Context, table string, pipeline interface{}, dest interface{}) error
+	Aggregate(ctx context.Context, table string, pipeline Pipeline, dest interface{}) error
 	RawQuery(ctx context.Context, query string, args []interface{}, dest interface{}) error
 
-	BeginTx(ctx context.Context) (Transaction, error)
-	RunInTransaction(ctx context.Context, fn func(ctx context.Context, tx Transaction) error) error
+	// Stream walks the rows of FindMany through a server-side cursor instead of
+	// loading them all. model is only used for its table mapping, e.g. (*User)(nil).
+	Stream(ctx context.Context, table, alias string, model interface{}, opts *QueryOption) (Rows, error)
+	// StreamRaw is Stream for a raw query
+	StreamRaw(ctx context.Context, query string, args []interface{}) (Rows, error)
+
+	// BeginTx starts a transaction, or a savepoint when ctx already carries one
+	BeginTx(ctx context.Context, opts *TxOptions) (Transaction, error)
+	// RunInTransaction commits when fn returns nil and rolls back otherwise. The
+	// ctx passed to fn carries the transaction; nested calls use savepoints.
+	RunInTransaction(ctx context.Context, opts *TxOptions, fn func(ctx context.Context, tx Transaction) error) error
 
-	EnsureIndices(ctx context.Context, table string, indices []Index) error
-	DropIndices(ctx context.Context, table string, indices []Index) error
+	EnsureIndices(ctx context.Context, table string, indices []Index) (*IndexReport, error)
+	DropIndices(ctx context.Context, table string, indices []Index) (*IndexReport, error)
 }
@@ -0,0 +1,56 @@
+package datastore
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors returned by every DataStore implementation. Match them with
+// errors.Is; use errors.As with *Error for the constraint details.
+var (
+	ErrNotFound       = errors.New("datastore: not found")
+	ErrDuplicate      = errors.New("datastore: duplicate key")
+	ErrForeignKey     = errors.New("datastore: foreign key violation")
+	ErrCheckViolation = errors.New("datastore: check violation")
+	ErrTimeout        = errors.New("datastore: timeout")
+	// ErrConflict is returned by Update when the row's version column no
+	// longer holds the version the model was read with
+	ErrConflict = errors.New("datastore: version conflict")
+)
+
+// Error is a datastore failure of a known Kind, one of the sentinels above,
+// with the details the database reported
+type Error struct {
+	Kind       error
+	Table      string
+	Constraint string
+	Column     string
+	Detail     string
+	Err        error // the driver error
+}
+
+func (e *Error) Error() string {
+	var details []string
+	if e.Table != "" {
+		details = append(details, "table "+e.Table)
+	}
+	if e.Constraint != "" {
+		details = append(details, "constraint "+e.Constraint)
+	}
+	if e.Column != "" {
+		details = append(details, "column "+e.Column)
+	}
+	if len(details) == 0 {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + " (" + strings.Join(details, ", ") + ")"
+}
+
+// Is matches the error's Kind
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,20 @@
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("pq: duplicate key value violates unique constraint")
+	err := fmt.Errorf("create user: %w", &Error{Kind: ErrDuplicate, Table: "users", Constraint: "users_email_key", Column: "email", Err: cause})
+
+	assert.ErrorIs(t, err, ErrDuplicate)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "create user: datastore: duplicate key (table users, constraint users_email_key, column email)", err.Error())
+	assert.Equal(t, "datastore: not found", (&Error{Kind: ErrNotFound}).Error())
+}
@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+)
+
+// Aggregate compiles pipeline to a single SELECT and scans the result into dest.
+// Stages are folded into the same SELECT while SQL evaluation order allows it;
+// otherwise the previous stages become a subquery. A subquery does not keep
+// its order, so only Limit stages may follow a Sort.
+func (s *Store) Aggregate(ctx context.Context, table string, pipeline datastore.Pipeline, dest interface{}) error {
+	if dest == nil {
+		return fmt.Errorf("dest cannot be nil")
+	}
+	query, args, err := compilePipeline(table, pipeline)
+	if err != nil {
+		return err
+	}
+	return translate(s.conn(ctx).NewRaw(query, args...).Scan(ctx, dest))
+}
+
+// aggSelect is the SELECT a pipeline is being compiled into
+type aggSelect struct {
+	from      string
+	fromArgs  []interface{}
+	alias     string
+	columns   []string
+	where     []string
+	args      []interface{}
+	group     []string
+	order     []string
+	orderArgs []interface{}
+	limit     int64
+}
+
+func (a *aggSelect) plain() bool {
+	return len(a.columns) == 0 && len(a.group) == 0 && len(a.order) == 0 && a.limit == 0
+}
+
+func (a *aggSelect) render() (string, []interface{}) {
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	if len(a.columns) == 0 {
+		b.WriteString("*")
+	} else {
+		b.WriteString(strings.Join(a.columns, ", "))
+	}
+	fmt.Fprintf(&b, " FROM %s AS %s", a.from, quoteIdent(a.alias))
+	if len(a.where) > 0 {
+		b.WriteString(" WHERE " + strings.Join(a.where, " AND "))
+	}
+	if len(a.group) > 0 {
+		b.WriteString(" GROUP BY " + strings.Join(a.group, ", "))
+	}
+	if len(a.order) > 0 {
+		b.WriteString(" ORDER BY " + strings.Join(a.order, ", "))
+	}
+	if a.limit > 0 {
+		fmt.Fprintf(&b, " LIMIT %d", a.limit)
+	}
+	args := append(append([]interface{}(nil), a.fromArgs...), a.args...)
+	return b.String(), append(args, a.orderArgs...)
+}
+
+// compilePipeline renders pipeline over table into SQL with bun placeholders
+func compilePipeline(table string, pipeline datastore.Pipeline) (string, []interface{}, error) {
+	from, err := quoteTable(table)
+	if err != nil {
+		return "", nil, err
+	}
+	cur := &aggSelect{from: from, alias: "s0"}
+	depth := 0
+	sortedAt := -1
+	wrap := func() {
+		query, args := cur.render()
+		depth++
+		cur = &aggSelect{from: "(" + query + ")", fromArgs: args, alias: fmt.Sprintf("s%d", depth)}
+	}
+
+	for i, st := range pipeline {
+		if _, ok := st.(datastore.LimitStage); !ok && sortedAt >= 0 {
+			return "", nil, fmt.Errorf("stage %d: only Limit may follow the sort at stage %d, its order would be lost", i, sortedAt)
+		}
+
+		switch st := st.(type) {
+		case datastore.MatchStage:
+			if !cur.plain() {
+				wrap()
+			}
+			where, args, err := buildFilter(st, cur.alias)
+			if err != nil {
+				return "", nil, fmt.Errorf("stage %d: %w", i, err)
+			}
+			if where != "" {
+				cur.where = append(cur.where, "("+where+")")
+				cur.args = append(cur.args, args...)
+			}
+
+		case datastore.GroupStage:
+			if !cur.plain() {
+				wrap()
+			}
+			if err := compileGroup(cur, st); err != nil {
+				return "", nil, fmt.Errorf("stage %d: %w", i, err)
+			}
+
+		case datastore.ProjectStage:
+			if !cur.plain() {
+				wrap()
+			}
+			if len(st) == 0 {
+				return "", nil, fmt.Errorf("stage %d: empty projection", i)
+			}
+			for _, p := range st {
+				col, err := aggColumn(cur.alias, p.Field, p.As, p.Bucket)
+				if err != nil {
+					return "", nil, fmt.Errorf("stage %d: %w", i, err)
+				}
+				cur.columns = append(cur.columns, col)
+			}
+
+		case datastore.SortStage:
+			// ORDER BY may only name output columns once the SELECT has a shape
+			if !cur.plain() {
+				wrap()
+			}
+			for _, f := range st {
+				expr, args, err := sortExpr(f, cur.alias)
+				if err != nil {
+					return "", nil, fmt.Errorf("stage %d: %w", i, err)
+				}
+				cur.order = append(cur.order, expr)
+				cur.orderArgs = append(cur.orderArgs, args...)
+			}
+			sortedAt = i
+
+		case datastore.LimitStage:
+			if st <= 0 {
+				return "", nil, fmt.Errorf("stage %d: limit must be positive", i)
+			}
+			// Consecutive limits keep the smallest, without a subquery losing the order
+			if cur.limit == 0 || int64(st) < cur.limit {
+				cur.limit = int64(st)
+			}
+
+		default:
+			return "", nil, fmt.Errorf("stage %d: unsupported stage %T", i, st)
+		}
+	}
+
+	query, args := cur.render()
+	return query, args, nil
+}
+
+func compileGroup(cur *aggSelect, g datastore.GroupStage) error {
+	if len(g.By) == 0 && len(g.Fields) == 0 {
+		return fmt.Errorf("empty group")
+	}
+	for _, k := range g.By {
+		col, err := qualifiedColumn(cur.alias, k.Field)
+		if err != nil {
+			return err
+		}
+		expr, err := bucketExpr(col, k.Bucket)
+		if err != nil {
+			return err
+		}
+		out, err := aggColumn(cur.alias, k.Field, k.As, k.Bucket)
+		if err != nil {
+			return err
+		}
+		cur.columns = append(cur.columns, out)
+		cur.group = append(cur.group, expr)
+	}
+	for _, f := range g.Fields {
+		if !identifierPattern.MatchString(f.As) {
+			return fmt.Errorf("invalid accumulator name %q", f.As)
+		}
+		expr, err := accumulatorExpr(cur.alias, f)
+		if err != nil {
+			return err
+		}
+		cur.columns = append(cur.columns, expr+" AS "+quoteIdent(f.As))
+	}
+	return nil
+}
+
+func accumulatorExpr(alias string, f datastore.Accumulator) (string, error) {
+	var fn string
+	switch f.Op {
+	case datastore.AggSum, datastore.AggAvg, datastore.AggMin, datastore.AggMax, datastore.AggCount:
+		fn = strings.ToUpper(string(f.Op))
+	default:
+		return "", fmt.Errorf("%s: unsupported accumulator %q", f.As, f.Op)
+	}
+
+	if f.Field == "" {
+		if f.Op != datastore.AggCount || f.Distinct {
+			return "", fmt.Errorf("%s: %s requires a field", f.As, f.Op)
+		}
+		return "COUNT(*)", nil
+	}
+	col, err := qualifiedColumn(alias, f.Field)
+	if err != nil {
+		return "", err
+	}
+	if f.Distinct {
+		col = "DISTINCT " + col
+	}
+	return fn + "(" + col + ")", nil
+}
+
+// aggColumn renders a column, optionally bucketed, named as (or after the field)
+func aggColumn(alias, field, as string, bucket datastore.DateBucket) (string, error) {
+	col, err := qualifiedColumn(alias, field)
+	if err != nil {
+		return "", err
+	}
+	expr, err := bucketExpr(col, bucket)
+	if err != nil {
+		return "", err
+	}
+	if as == "" {
+		as = field[strings.LastIndex(field, ".")+1:]
+	}
+	if !identifierPattern.MatchString(as) {
+		return "", fmt.Errorf("invalid column alias %q", as)
+	}
+	return expr + " AS " + quoteIdent(as), nil
+}
+
+func bucketExpr(col string, bucket datastore.DateBucket) (string, error) {
+	switch bucket {
+	case "":
+		return col, nil
+	case datastore.BucketMinute, datastore.BucketHour, datastore.BucketDay, datastore.BucketWeek,
+		datastore.BucketMonth, datastore.BucketQuarter, datastore.BucketYear:
+		return fmt.Sprintf("date_trunc('%s', %s)", bucket, col), nil
+	default:
+		return "", fmt.Errorf("unsupported date bucket %q", bucket)
+	}
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestCompilePipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline datastore.Pipeline
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Empty",
+			pipeline: datastore.Pipeline{},
+			want:     `SELECT * FROM "orders" AS "s0"`,
+		},
+		{
+			name: "MatchGroupSortLimit",
+			pipeline: datastore.Pipeline{
+				datastore.MatchStage{"status": "paid"},
+				datastore.GroupStage{
+					By: []datastore.GroupKey{{Field: "created_at", As: "day", Bucket: datastore.BucketDay}},
+					Fields: []datastore.Accumulator{
+						{As: "revenue", Op: datastore.AggSum, Field: "amount"},
+						{As: "orders", Op: datastore.AggCount},
+						{As: "buyers", Op: datastore.AggCount, Field: "user_id", Distinct: true},
+					},
+				},
+				datastore.SortStage{{Field: "day", Order: datastore.Desc}},
+				datastore.LimitStage(30),
+			},
+			want: `SELECT * FROM (SELECT date_trunc('day', "s0"."created_at") AS "day", SUM("s0"."amount") AS "revenue", COUNT(*) AS "orders", COUNT(DISTINCT "s0"."user_id") AS "buyers" FROM "orders" AS "s0" WHERE ("s0"."status" = 'paid') GROUP BY date_trunc('day', "s0"."created_at")) AS "s1" ORDER BY "s1"."day" DESC LIMIT 30`,
+		},
+		{
+			name: "MatchAfterGroup",
+			pipeline: datastore.Pipeline{
+				datastore.GroupStage{
+					By:     []datastore.GroupKey{{Field: "user_id"}},
+					Fields: []datastore.Accumulator{{As: "total", Op: datastore.AggAvg, Field: "amount"}},
+				},
+				datastore.MatchStage{"total__gt": 100},
+			},
+			want: `SELECT * FROM (SELECT "s0"."user_id" AS "user_id", AVG("s0"."amount") AS "total" FROM "orders" AS "s0" GROUP BY "s0"."user_id") AS "s1" WHERE ("s1"."total" > 100)`,
+		},
+		{
+			name: "SortThenLimits",
+			pipeline: datastore.Pipeline{
+				datastore.SortStage{{Field: "created_at", Order: datastore.Desc}},
+				datastore.LimitStage(5),
+				datastore.LimitStage(10),
+			},
+			want: `SELECT * FROM "orders" AS "s0" ORDER BY "s0"."created_at" DESC LIMIT 5`,
+		},
+		{
+			name: "LimitThenMatch",
+			pipeline: datastore.Pipeline{
+				datastore.LimitStage(10),
+				datastore.MatchStage{"status": "paid"},
+			},
+			want: `SELECT * FROM (SELECT * FROM "orders" AS "s0" LIMIT 10) AS "s1" WHERE ("s1"."status" = 'paid')`,
+		},
+		{
+			name: "Project",
+			pipeline: datastore.Pipeline{
+				datastore.MatchStage{"amount__gte": 10},
+				datastore.ProjectStage{{Field: "id"}, {Field: "created_at", As: "month", Bucket: datastore.BucketMonth}},
+				datastore.MatchStage{"month": "2026-01-01"},
+			},
+			want: `SELECT * FROM (SELECT "s0"."id" AS "id", date_trunc('month', "s0"."created_at") AS "month" FROM "orders" AS "s0" WHERE ("s0"."amount" >= 10)) AS "s1" WHERE ("s1"."month" = '2026-01-01')`,
+		},
+		{
+			name: "SortExprArgs",
+			pipeline: datastore.Pipeline{
+				datastore.MatchStage{"status": "paid"},
+				datastore.SortStage{{Expr: "abs(amount - ?)", Args: []interface{}{50}}},
+			},
+			want: `SELECT * FROM "orders" AS "s0" WHERE ("s0"."status" = 'paid') ORDER BY abs(amount - 50) ASC`,
+		},
+		{name: "UnknownAccumulator", pipeline: datastore.Pipeline{datastore.GroupStage{Fields: []datastore.Accumulator{{As: "x", Op: "median", Field: "amount"}}}}, wantErr: true},
+		{name: "SumWithoutField", pipeline: datastore.Pipeline{datastore.GroupStage{Fields: []datastore.Accumulator{{As: "x", Op: datastore.AggSum}}}}, wantErr: true},
+		{name: "InvalidAccumulatorName", pipeline: datastore.Pipeline{datastore.GroupStage{Fields: []datastore.Accumulator{{As: "x y", Op: datastore.AggCount}}}}, wantErr: true},
+		{name: "UnknownBucket", pipeline: datastore.Pipeline{datastore.GroupStage{By: []datastore.GroupKey{{Field: "created_at", Bucket: "fortnight"}}}}, wantErr: true},
+		{name: "EmptyGroup", pipeline: datastore.Pipeline{datastore.GroupStage{}}, wantErr: true},
+		{name: "EmptyProject", pipeline: datastore.Pipeline{datastore.ProjectStage{}}, wantErr: true},
+		{name: "InvalidField", pipeline: datastore.Pipeline{datastore.ProjectStage{{Field: "id; --"}}}, wantErr: true},
+		{name: "NonPositiveLimit", pipeline: datastore.Pipeline{datastore.LimitStage(0)}, wantErr: true},
+		{name: "NilStage", pipeline: datastore.Pipeline{nil}, wantErr: true},
+		{name: "MatchAfterSort", pipeline: datastore.Pipeline{datastore.SortStage{{Field: "amount"}}, datastore.MatchStage{"status": "paid"}}, wantErr: true},
+		{name: "ProjectAfterSortLimit", pipeline: datastore.Pipeline{datastore.SortStage{{Field: "amount"}}, datastore.LimitStage(3), datastore.ProjectStage{{Field: "id"}}}, wantErr: true},
+		{name: "SortAfterSort", pipeline: datastore.Pipeline{datastore.SortStage{{Field: "amount"}}, datastore.SortStage{{Field: "id"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := render(t, func(db *bun.DB) (fmt.Stringer, error) {
+				query, args, err := compilePipeline("orders", tt.pipeline)
+				return db.NewRaw(query, args...), err
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompilePipelineInvalidTable(t *testing.T) {
+	_, _, err := compilePipeline("orders; DROP TABLE users", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+)
+
+const (
+	defaultChunkSize = 1000
+	// maxBindParams is the most placeholders the Postgres protocol allows per statement
+	maxBindParams = 65535
+)
+
+// InsertMany inserts data, a slice or pointer to a slice of models, in chunks
+// of multi-row INSERTs and scans the generated columns back into each model.
+// More than one chunk runs in a transaction, so the load is all or nothing.
+func (s *Store) InsertMany(ctx context.Context, table string, data interface{}, opts *datastore.BulkOption) (*datastore.WriteResult, error) {
+	rows := reflect.Indirect(reflect.ValueOf(data))
+	if rows.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("insert many: data must be a slice of models, got %T", data)
+	}
+	elem := rows.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("insert many: data must be a slice of models, got %T", data)
+	}
+	quoted, err := quoteTable(table)
+	if err != nil {
+		return nil, err
+	}
+	if rows.Len() == 0 {
+		return &datastore.WriteResult{}, nil
+	}
+
+	size := chunkSize(opts, len(s.db.Table(elem).Fields))
+	insert := func(ctx context.Context) (*datastore.WriteResult, error) {
+		total := &datastore.WriteResult{}
+		for i := 0; i < rows.Len(); i += size {
+			res, err := writeResult(insertManyQuery(s.conn(ctx), quoted, rows.Slice(i, min(i+size, rows.Len()))).Exec(ctx))
+			if err != nil {
+				return nil, err
+			}
+			total.RowsAffected += res.RowsAffected
+		}
+		return total, nil
+	}
+
+	if rows.Len() <= size {
+		return insert(ctx)
+	}
+	var res *datastore.WriteResult
+	err = s.RunInTransaction(ctx, nil, func(ctx context.Context, _ datastore.Transaction) error {
+		res, err = insert(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// insertManyQuery builds the INSERT of one chunk. The chunk shares its backing
+// array with the caller's slice, so RETURNING fills in the caller's models.
+func insertManyQuery(db bun.IDB, table string, chunk reflect.Value) *bun.InsertQuery {
+	ptr := reflect.New(chunk.Type())
+	ptr.Elem().Set(chunk)
+	q := db.NewInsert().Model(ptr.Interface()).ModelTableExpr(table)
+	if cols := returningColumns(q); cols != "" {
+		q = q.Returning(cols)
+	}
+	return q
+}
+
+// chunkSize returns the rows per INSERT for a model with the given number of columns
+func chunkSize(opts *datastore.BulkOption, columns int) int {
+	size := defaultChunkSize
+	if opts != nil && opts.ChunkSize > 0 {
+		size = opts.ChunkSize
+	}
+	if columns > 0 && size*columns > maxBindParams {
+		size = maxBindParams / columns
+	}
+	return size
+}
+
+// CopyFrom streams rows into columns of table through COPY FROM STDIN. It
+// joins the transaction carried by ctx, as a savepoint, or starts its own.
+func (s *Store) CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq2[[]interface{}, error]) (*datastore.WriteResult, error) {
+	stmt, err := copyStatement(table, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &datastore.WriteResult{}
+	// runOnce rather than RunInTransaction: rows cannot be replayed on a retry
+	err = s.runOnce(ctx, nil, func(ctx context.Context, _ datastore.Transaction) error {
+		v, _ := s.txFrom(ctx)
+		st, err := v.tx.Tx.PrepareContext(ctx, stmt)
+		if err != nil {
+			return translate(err)
+		}
+		defer st.Close()
+
+		for values, err := range rows {
+			if err != nil {
+				return fmt.Errorf("copy %s: row %d: %w", table, res.RowsAffected+1, err)
+			}
+			if len(values) != len(columns) {
+				return fmt.Errorf("copy %s: row %d has %d values for %d columns", table, res.RowsAffected+1, len(values), len(columns))
+			}
+			if _, err = st.ExecContext(ctx, values...); err != nil {
+				return translate(err)
+			}
+			res.RowsAffected++
+		}
+		// An Exec without values flushes the buffered rows and ends the COPY
+		if _, err = st.ExecContext(ctx); err != nil {
+			return translate(err)
+		}
+		return st.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// copyStatement validates table and columns and renders the COPY statement pq expects
+func copyStatement(table string, columns []string) (string, error) {
+	if len(columns) == 0 {
+		return "", fmt.Errorf("copy: columns are required")
+	}
+	if _, err := quoteTable(table); err != nil {
+		return "", err
+	}
+	for _, c := range columns {
+		if !identifierPattern.MatchString(c) {
+			return "", fmt.Errorf("copy: invalid column %q", c)
+		}
+	}
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		return pq.CopyInSchema(schema, name, columns...), nil
+	}
+	return pq.CopyIn(table, columns...), nil
+}
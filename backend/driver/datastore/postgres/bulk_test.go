@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *datastore.BulkOption
+		columns  int
+		expected int
+	}{
+		{name: "Default", columns: 3, expected: 1000},
+		{name: "Configured", opts: &datastore.BulkOption{ChunkSize: 250}, columns: 3, expected: 250},
+		{name: "CappedByBindParams", opts: &datastore.BulkOption{ChunkSize: 10000}, columns: 20, expected: 3276},
+		{name: "DefaultCappedForWideModel", columns: 100, expected: 655},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, chunkSize(tt.opts, tt.columns))
+		})
+	}
+}
+
+func TestInsertManyQuery(t *testing.T) {
+	rows := []pageModel{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}}
+	q := insertManyQuery(newTestStore(t).db, `"events"`, reflect.ValueOf(rows).Slice(1, 3))
+	assert.Equal(t,
+		`INSERT INTO "events" ("id", "name", "starts_at") VALUES (2, 'b', NULL), (3, 'c', NULL) RETURNING "id", "name", "starts_at"`,
+		q.String(),
+	)
+}
+
+func TestInsertManyValidation(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	res, err := s.InsertMany(ctx, "events", []pageModel{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), res.RowsAffected)
+
+	_, err = s.InsertMany(ctx, "events", []interface{}{&pageModel{}}, nil)
+	assert.ErrorContains(t, err, "slice of models")
+	_, err = s.InsertMany(ctx, "events", &pageModel{}, nil)
+	assert.ErrorContains(t, err, "slice of models")
+	_, err = s.InsertMany(ctx, "events; --", []pageModel{{ID: 1}}, nil)
+	assert.Error(t, err)
+}
+
+func TestCopyStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    string
+		columns  []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Table",
+			table:    "events",
+			columns:  []string{"id", "name"},
+			expected: `COPY "events" ("id", "name") FROM STDIN`,
+		},
+		{
+			name:     "SchemaQualified",
+			table:    "analytics.page_views",
+			columns:  []string{"viewed_at", "path"},
+			expected: `COPY "analytics"."page_views" ("viewed_at", "path") FROM STDIN`,
+		},
+		{name: "NoColumns", table: "events", wantErr: true},
+		{name: "InvalidColumn", table: "events", columns: []string{"id) FROM PROGRAM 'x' --"}, wantErr: true},
+		{name: "InvalidTable", table: "events e", columns: []string{"id"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := copyStatement(tt.table, tt.columns)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stmt)
+		})
+	}
+}
@@ -21,7 +21,9 @@ import (
 
 // Store implements the datastore.DataStore interface
 type Store struct {
-	db *bun.DB
+	db        *bun.DB
+	cursorKey []byte
+	retry     datastore.RetryPolicy
 }
 
 // NewStore initializes a new PostgresSQL Bun connection
@@ -50,7 +52,15 @@ func NewStore(cfg *config.Config, l *slog.Logger) *Store {
 		os.Exit(1)
 	}
 	l.Info("Connected to Postgres successfully")
-	return &Store{db: db}
+	return &Store{
+		db:        db,
+		cursorKey: cursorKey(cfg),
+		retry: datastore.RetryPolicy{
+			MaxAttempts: cfg.TxMaxAttempts,
+			BaseDelay:   cfg.TxRetryBaseDelay,
+			MaxDelay:    cfg.TxRetryMaxDelay,
+		},
+	}
 }
 
 // DB exposes the underlying *bun.DB (for advanced usage)
@@ -70,69 +80,138 @@ func (s *Store) Disconnect(ctx context.Context) error {
 	return s.db.Close()
 }
 
-func (s *Store) Insert(ctx context.Context, table string, data interface{}) error {
-	_, err := s.db.NewInsert().
+func (s *Store) Insert(ctx context.Context, table string, data interface{}) (*datastore.WriteResult, error) {
+	if data == nil {
+		return nil, fmt.Errorf("data cannot be nil")
+	}
+	quoted, err := quoteTable(table)
+	if err != nil {
+		return nil, err
+	}
+	q := s.conn(ctx).NewInsert().
 		Model(data).
-		ModelTableExpr(table).
-		Exec(ctx)
-	return err
+		ModelTableExpr(quoted)
+	if cols := returningColumns(q); cols != "" {
+		q = q.Returning(cols)
+	}
+	return writeResult(q.Exec(ctx))
 }
 
-func (s *Store) InsertMany(ctx context.Context, table string, data []interface{}) error {
-	_, err := s.db.NewInsert().Model(&data).ModelTableExpr(table).Exec(ctx)
-	return err
+// Update writes the non-zero fields of data to the row of table matching
+// filter and scans the updated row back into data. A versioned model fails
+// with datastore.ErrConflict when the row no longer holds its version.
+func (s *Store) Update(ctx context.Context, table string, filter map[string]interface{}, data interface{}) (*datastore.WriteResult, error) {
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("update: filter cannot be empty")
+	}
+	q, versioned, err := updateQuery(s.conn(ctx), table, filter, data, true)
+	if err != nil {
+		return nil, err
+	}
+	res, err := writeResult(q.Exec(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if versioned && res.RowsAffected == 0 {
+		return nil, &datastore.Error{Kind: datastore.ErrConflict, Table: table}
+	}
+	return res, nil
 }
 
-func (s *Store) Update(ctx context.Context, filter map[string]interface{}, data interface{}) error {
-	q := s.db.NewUpdate().Model(data).OmitZero()
+// UpdateMany writes the non-zero fields of data to every row of table
+// matching filter. A versioned model has the version of each row incremented,
+// without comparing it.
+func (s *Store) UpdateMany(ctx context.Context, table string, filter map[string]interface{}, data interface{}) (*datastore.WriteResult, error) {
+	q, _, err := updateQuery(s.conn(ctx), table, filter, data, false)
+	if err != nil {
+		return nil, err
+	}
+	return writeResult(q.Exec(ctx))
+}
 
-	for k, v := range filter {
-		q = q.Where(fmt.Sprintf("%s = ?", k), v)
+// updateQuery builds an UPDATE of table from the non-zero fields of data,
+// restricted to the rows matching filter. A single-row update also returns the
+// row and, for a versioned model, matches its version; versioned reports the latter.
+func updateQuery(db bun.IDB, table string, filter map[string]interface{}, data interface{}, single bool) (q *bun.UpdateQuery, versioned bool, err error) {
+	if data == nil {
+		return nil, false, fmt.Errorf("data cannot be nil")
+	}
+	q = db.NewUpdate().Model(data)
+	expr, alias, err := modelTable(q, table, "")
+	if err != nil {
+		return nil, false, err
+	}
+	where, args, err := buildFilter(filter, alias)
+	if err != nil {
+		return nil, false, err
+	}
+	q = q.ModelTableExpr(expr).OmitZero()
+	if where != "" {
+		q = q.Where(where, args...)
 	}
 
-	_, err := q.Exec(ctx)
-	return err
+	if version := versionField(q); version != nil {
+		col := quoteIdent(alias) + "." + quoteIdent(version.Name)
+		q = q.ExcludeColumn(version.Name).
+			Set(quoteIdent(version.Name) + " = " + col + " + 1")
+		if single {
+			q = q.Where(col+" = ?", version.Value(reflect.Indirect(reflect.ValueOf(data))).Interface())
+			versioned = true
+		}
+	}
+	if single {
+		if cols := returningColumns(q); cols != "" {
+			q = q.Returning(cols)
+		}
+	}
+	return q, versioned, nil
 }
 
-func (s *Store) UpdateMany(ctx context.Context, filter map[string]interface{}, data interface{}) error {
-	return s.Update(ctx, filter, data)
+// Delete removes the row of table matching filter
+func (s *Store) Delete(ctx context.Context, table string, filter map[string]interface{}) (*datastore.WriteResult, error) {
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("delete: filter cannot be empty")
+	}
+	return s.delete(ctx, table, filter)
 }
 
-func (s *Store) Upsert(ctx context.Context, table string, filter map[string]interface{}, data interface{}) error {
-	q := s.db.NewInsert().Model(data).ModelTableExpr(table)
-	for k := range filter {
-		q = q.On("CONFLICT (" + k + ") DO UPDATE").Set(fmt.Sprintf("%s = EXCLUDED.%s", k, k))
-		break
-	}
-	_, err := q.Exec(ctx)
-	return err
+// DeleteMany removes every row of table matching filter
+func (s *Store) DeleteMany(ctx context.Context, table string, filter map[string]interface{}) (*datastore.WriteResult, error) {
+	return s.delete(ctx, table, filter)
 }
 
-func (s *Store) Delete(ctx context.Context, table string, filter map[string]interface{}) error {
-	q := s.db.NewDelete().ModelTableExpr(table)
-	for k, v := range filter {
-		q = q.Where(fmt.Sprintf("%s = ?", k), v)
+func (s *Store) delete(ctx context.Context, table string, filter map[string]interface{}) (*datastore.WriteResult, error) {
+	q, err := deleteQuery(s.conn(ctx).NewDelete(), table, filter)
+	if err != nil {
+		return nil, err
 	}
-	_, err := q.Exec(ctx)
-	return err
+	return writeResult(q.Exec(ctx))
 }
 
-func (s *Store) DeleteMany(ctx context.Context, table string, filter map[string]interface{}) error {
-	return s.Delete(ctx, table, filter)
+// deleteQuery points q at table and restricts it to the rows matching filter
+func deleteQuery(q *bun.DeleteQuery, table string, filter map[string]interface{}) (*bun.DeleteQuery, error) {
+	expr, alias, err := tableExpr(table, "")
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := buildFilter(filter, alias)
+	if err != nil {
+		return nil, err
+	}
+	q = q.TableExpr(expr)
+	if where != "" {
+		q = q.Where(where, args...)
+	}
+	return q, nil
 }
 
 // FindOne fetches a single record from the given table with optional alias and query options.
 func (s *Store) FindOne(ctx context.Context, table, alias string, dest interface{}, opts *datastore.QueryOption) error {
-	if dest == nil {
-		return fmt.Errorf("dest cannot be nil")
+	q, err := s.selectQuery(ctx, table, alias, dest, opts)
+	if err != nil {
+		return err
 	}
-
-	q := s.db.NewSelect().
-		Model(dest)
-
-	applyQueryOptions(q, opts, alias)
-
-	return q.Scan(ctx)
+	return translate(q.Scan(ctx))
 }
 
 // FindMany fetches multiple records from the table with optional alias and query options.
@@ -142,31 +221,79 @@ func (s *Store) FindMany(
 	dest interface{},
 	opts *datastore.QueryOption,
 ) error {
+	q, err := s.selectQuery(ctx, table, alias, dest, opts)
+	if err != nil {
+		return err
+	}
+	return translate(q.Scan(ctx))
+}
+
+// selectQuery builds a SELECT of dest from table AS alias with opts applied
+func (s *Store) selectQuery(ctx context.Context, table, alias string, dest interface{}, opts *datastore.QueryOption) (*bun.SelectQuery, error) {
 	if dest == nil {
-		return fmt.Errorf("dest cannot be nil")
+		return nil, fmt.Errorf("dest cannot be nil")
 	}
+	q := s.conn(ctx).NewSelect().Model(dest)
+	expr, alias, err := modelTable(q, table, alias)
+	if err != nil {
+		return nil, err
+	}
+	q = q.ModelTableExpr(expr)
 
-	// FIX: Reverting to the canonical TableExpr to resolve the 'WrapWith undefined' compilation error.
-	q := s.db.NewSelect().
-		Model(dest)
+	if err = applyQueryOptions(q, opts, alias); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
 
-	applyQueryOptions(q, opts, alias)
+// modelTable renders table AS alias for the model of q, defaulting either to
+// the model's own mapping, and returns the alias in effect. Relations joined
+// by bun always refer to the model's alias, so only override it without them.
+func modelTable(q interface{ GetModel() bun.Model }, table, alias string) (string, string, error) {
+	if t := modelSchema(q); t != nil {
+		if table == "" {
+			table = t.Name
+		}
+		if alias == "" {
+			alias = string(t.Alias)
+		}
+	}
+	if table == "" {
+		return "", "", fmt.Errorf("table is required")
+	}
+	return tableExpr(table, alias)
+}
 
-	return q.Scan(ctx)
+// tableExpr renders table AS alias, using the unqualified table name when alias is empty
+func tableExpr(table, alias string) (string, string, error) {
+	quoted, err := quoteTable(table)
+	if err != nil {
+		return "", "", err
+	}
+	if alias == "" {
+		alias = table[strings.LastIndex(table, ".")+1:]
+	}
+	if !identifierPattern.MatchString(alias) {
+		return "", "", fmt.Errorf("invalid table alias %q", alias)
+	}
+	return quoted + " AS " + quoteIdent(alias), alias, nil
 }
 
 // applyQueryOptions applies QueryOption struct to a Bun SelectQuery
-func applyQueryOptions(q *bun.SelectQuery, opts *datastore.QueryOption, alias string) {
+func applyQueryOptions(q *bun.SelectQuery, opts *datastore.QueryOption, alias string) error {
+	// --- SELECT main table columns ---
+	q = q.ColumnExpr(quoteIdent(alias) + ".*")
 	if opts == nil {
-		return
+		return nil
 	}
 
-	// --- SELECT main table columns ---
-	q = q.Column(fmt.Sprintf(`%s.*`, alias))
-
 	// --- Select extra columns if needed ---
 	for _, c := range opts.Select {
-		q = q.Column(fmt.Sprintf(`"%s"."%s"`, alias, c))
+		col, err := qualifiedColumn(alias, c)
+		if err != nil {
+			return err
+		}
+		q = q.ColumnExpr(col)
 	}
 
 	// --- Relations ---
@@ -174,38 +301,31 @@ func applyQueryOptions(q *bun.SelectQuery, opts *datastore.QueryOption, alias st
 		q = q.Relation(rel) // Bun will handle JOINs and nested structs automatically
 	}
 
+	// --- Joins ---
+	if err := applyJoins(q, opts.Join); err != nil {
+		return err
+	}
+
 	// --- Filters ---
-	for k, v := range opts.Filter {
-		switch {
-		// ✅ Support IS NULL
-		case strings.HasSuffix(k, "__is_null"):
-			col := strings.TrimSuffix(k, "__is_null")
-			q = q.Where(fmt.Sprintf(`"%s"."%s" IS NULL`, alias, col))
-
-		// ✅ Support IS NOT NULL
-		case strings.HasSuffix(k, "__is_not_null"):
-			col := strings.TrimSuffix(k, "__is_not_null")
-			q = q.Where(fmt.Sprintf(`"%s"."%s" IS NOT NULL`, alias, col))
-
-		// ✅ Support IN (slice values)
-		case strings.HasSuffix(k, "__in"):
-			col := strings.TrimSuffix(k, "__in")
-			q = q.Where(fmt.Sprintf(`"%s"."%s" IN (?)`, alias, col), bun.In(v))
-
-		// ✅ Support not equal
-		case strings.HasSuffix(k, "__ne"):
-			col := strings.TrimSuffix(k, "__ne")
-			q = q.Where(fmt.Sprintf(`"%s"."%s" != ?`, alias, col), v)
-
-		// ✅ Default equal
-		default:
-			q = q.Where(fmt.Sprintf(`"%s"."%s" = ?`, alias, k), v)
-		}
+	where, args, err := buildFilter(opts.Filter, alias)
+	if err != nil {
+		return err
+	}
+	if where != "" {
+		q = q.Where(where, args...)
+	}
+
+	// --- Grouping ---
+	if err = applyGroup(q, opts.Group, alias); err != nil {
+		return err
+	}
+	if err = applyHaving(q, opts.Having, alias); err != nil {
+		return err
 	}
 
 	// --- Sorting ---
-	for field, order := range opts.Sort {
-		q = q.Order(fmt.Sprintf(`%s.%s %s`, alias, field, order))
+	if err = applySort(q, opts, alias); err != nil {
+		return err
 	}
 
 	// --- Distinct ---
@@ -220,138 +340,42 @@ func applyQueryOptions(q *bun.SelectQuery, opts *datastore.QueryOption, alias st
 	if opts.Skip > 0 {
 		q = q.Offset(int(opts.Skip))
 	}
-}
-
-func (s *Store) Count(ctx context.Context, table, alias string, model interface{}, opts *datastore.QueryOption) (int, error) {
-	q := s.db.NewSelect().Model(model)
-	applyQueryOptions(q, opts, alias)
-	return q.Count(ctx)
-}
-
-func (s *Store) Distinct(ctx context.Context, table, field string, filter map[string]interface{}, dest interface{}) error {
-	q := s.db.NewSelect().ModelTableExpr(table).ColumnExpr("DISTINCT ?", bun.Ident(field))
-	for k, v := range filter {
-		q = q.Where(fmt.Sprintf("%s = ?", k), v)
-	}
-	return q.Scan(ctx, dest)
-}
-
-func (s *Store) Aggregate(ctx context.Context, table string, pipeline interface{}, dest interface{}) error {
-	return fmt.Errorf("aggregate not implemented; use RawQuery instead")
-}
-
-func (s *Store) RawQuery(ctx context.Context, query string, args []interface{}, dest interface{}) error {
-	return s.db.NewRaw(query, args...).Scan(ctx, dest)
-}
-
-func (s *Store) EnsureIndices(ctx context.Context, table string, indices []datastore.Index) error {
-	// Implementation depends on schema management needs
-	return nil
-}
-
-func (s *Store) DropIndices(ctx context.Context, table string, indices []datastore.Index) error {
 	return nil
 }
 
-// --- Transaction Management ---
-
-type pgTx struct {
-	tx bun.Tx
-}
-
-func (t *pgTx) Commit(ctx context.Context) error {
-	return t.tx.Commit()
-}
-
-func (t *pgTx) Rollback(ctx context.Context) error {
-	return t.tx.Rollback()
-}
-
-func (s *Store) BeginTx(ctx context.Context) (datastore.Transaction, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
+func (s *Store) Count(ctx context.Context, table, alias string, model interface{}, opts *datastore.QueryOption) (int, error) {
+	q, err := s.selectQuery(ctx, table, alias, model, opts)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	return &pgTx{tx: tx}, nil
-}
-
-func (s *Store) RunInTransaction(ctx context.Context, fn func(ctx context.Context, tx datastore.Transaction) error) error {
-	return s.db.RunInTx(ctx, nil, func(ctx context.Context, bunTx bun.Tx) error {
-		tx := &pgTx{tx: bunTx}
-		return fn(ctx, tx)
-	})
+	n, err := q.Count(ctx)
+	return n, translate(err)
 }
 
-func containsAny(s string, subs ...string) bool {
-	for _, sub := range subs {
-		if contains := len(sub) > 0 && len(s) >= len(sub) &&
-			(len(s) >= len(sub)) &&
-			(func() bool {
-				return len(s) > 0 && (len(sub) == 0 ||
-					(len(s) >= len(sub) && (s[len(s)-len(sub):] == sub || s[:len(sub)] == sub ||
-						len(s) > len(sub) && (containsAny(s[1:], sub)))))
-			})(); contains {
-			return true
-		}
+// Distinct scans the distinct values of field among the rows of table matching filter into dest
+func (s *Store) Distinct(ctx context.Context, table, field string, filter map[string]interface{}, dest interface{}) error {
+	expr, alias, err := tableExpr(table, "")
+	if err != nil {
+		return err
 	}
-	return false
-}
-
-func buildUpdateMap(data interface{}) (map[string]interface{}, error) {
-	updateMap := make(map[string]interface{})
-
-	v := reflect.ValueOf(data)
-	if v.Kind() != reflect.Ptr || v.IsNil() {
-		return nil, fmt.Errorf("data must be a non-nil pointer to struct")
+	col, err := qualifiedColumn(alias, field)
+	if err != nil {
+		return err
 	}
+	q := s.conn(ctx).NewSelect().TableExpr(expr).ColumnExpr("DISTINCT " + col)
 
-	v = v.Elem()
-	if v.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("data must point to a struct")
+	where, args, err := buildFilter(filter, alias)
+	if err != nil {
+		return err
 	}
-
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Type().Field(i)
-		value := v.Field(i)
-
-		if !value.CanInterface() {
-			continue
-		}
-
-		// Skip nil pointers
-		if value.Kind() == reflect.Ptr && value.IsNil() {
-			continue
-		}
-
-		// Skip nil or empty slices
-		if value.Kind() == reflect.Slice && (value.IsNil() || value.Len() == 0) {
-			continue
-		}
-
-		// Skip BaseModel or audit fields
-		if field.Type.Name() == "BaseModel" || field.Type.Name() == "UpdateAuditParam" {
-			continue
-		}
-
-		tag := field.Tag.Get("bun")
-		columnName := parseBunColumn(tag)
-		if columnName == "" {
-			columnName = field.Name
-		}
-
-		updateMap[columnName] = value.Interface()
+	if where != "" {
+		q = q.Where(where, args...)
 	}
-
-	return updateMap, nil
+	return translate(q.Scan(ctx, dest))
 }
 
-// parseBunColumn extracts the column name from the bun tag
-func parseBunColumn(tag string) string {
-	parts := strings.Split(tag, ",")
-	if len(parts) > 0 && parts[0] != "" {
-		return parts[0]
-	}
-	return ""
+func (s *Store) RawQuery(ctx context.Context, query string, args []interface{}, dest interface{}) error {
+	return translate(s.conn(ctx).NewRaw(query, args...).Scan(ctx, dest))
 }
 
 type QueryHook struct{}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestSelectQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		table       string
+		alias       string
+		opts        *datastore.QueryOption
+		expectedSQL string
+		wantErr     bool
+	}{
+		{
+			name:        "ModelDefaultsWithoutOptions",
+			expectedSQL: `SELECT "e".* FROM "events" AS "e"`,
+		},
+		{
+			name:        "TableAndAlias",
+			table:       "archive.events",
+			alias:       "ae",
+			opts:        &datastore.QueryOption{Filter: map[string]interface{}{"name": "launch"}, Limit: 5},
+			expectedSQL: `SELECT "ae".* FROM "archive"."events" AS "ae" WHERE ("ae"."name" = 'launch') LIMIT 5`,
+		},
+		{
+			name:        "TableKeepsModelAlias",
+			table:       "events_2025",
+			expectedSQL: `SELECT "e".* FROM "events_2025" AS "e"`,
+		},
+		{name: "InvalidTable", table: "events; DROP TABLE users", wantErr: true},
+		{name: "InvalidAlias", alias: "e e", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []pageModel
+			q, err := newTestStore(t).selectQuery(context.Background(), tt.table, tt.alias, &rows, tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSQL, q.String())
+		})
+	}
+}
+
+type versionedModel struct {
+	bun.BaseModel `bun:"table:documents,alias:d"`
+
+	ID      int64  `bun:"id,pk"`
+	Title   string `bun:"title"`
+	Version int64  `bun:"version,notnull,default:1,version"`
+}
+
+func TestUpdateQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		table         string
+		filter        map[string]interface{}
+		data          interface{}
+		single        bool
+		expectedSQL   string
+		wantVersioned bool
+		wantErr       bool
+	}{
+		{
+			name:        "SingleRowReturns",
+			table:       "events",
+			filter:      map[string]interface{}{"id": 1},
+			data:        &pageModel{Name: "renamed"},
+			single:      true,
+			expectedSQL: `UPDATE "events" AS "e" SET "name" = 'renamed' WHERE ("e"."id" = 1) RETURNING "id", "name", "starts_at"`,
+		},
+		{
+			name:        "ManyOnOtherTable",
+			table:       "archive.events",
+			filter:      map[string]interface{}{"starts_at__is_null": true},
+			data:        &pageModel{Name: "renamed"},
+			expectedSQL: `UPDATE "archive"."events" AS "e" SET "name" = 'renamed' WHERE ("e"."starts_at" IS NULL)`,
+		},
+		{
+			name:          "VersionChecked",
+			table:         "documents",
+			filter:        map[string]interface{}{"id": 3},
+			data:          &versionedModel{Title: "draft", Version: 4},
+			single:        true,
+			expectedSQL:   `UPDATE "documents" AS "d" SET "title" = 'draft', "version" = "d"."version" + 1 WHERE ("d"."id" = 3) AND ("d"."version" = 4) RETURNING "id", "title", "version"`,
+			wantVersioned: true,
+		},
+		{
+			name:        "VersionBumpedForMany",
+			table:       "documents",
+			filter:      map[string]interface{}{"title__like": "tmp%"},
+			data:        &versionedModel{Title: "archived", Version: 4},
+			expectedSQL: `UPDATE "documents" AS "d" SET "title" = 'archived', "version" = "d"."version" + 1 WHERE ("d"."title" LIKE 'tmp%')`,
+		},
+		{name: "InvalidColumn", table: "events", filter: map[string]interface{}{"id = 1 OR 1": 1}, data: &pageModel{Name: "x"}, wantErr: true},
+		{name: "InvalidTable", table: "events e", filter: map[string]interface{}{"id": 1}, data: &pageModel{Name: "x"}, wantErr: true},
+		{name: "NilData", table: "events", filter: map[string]interface{}{"id": 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, versioned, err := updateQuery(newTestStore(t).db, tt.table, tt.filter, tt.data, tt.single)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSQL, q.String())
+			assert.Equal(t, tt.wantVersioned, versioned)
+		})
+	}
+}
+
+func TestDeleteQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		table       string
+		filter      map[string]interface{}
+		expectedSQL string
+		wantErr     bool
+	}{
+		{
+			name:        "Filter",
+			table:       "sessions",
+			filter:      map[string]interface{}{"user_id": 7, "revoked_at__is_not_null": true},
+			expectedSQL: `DELETE FROM "sessions" AS "sessions" WHERE ("sessions"."revoked_at" IS NOT NULL AND "sessions"."user_id" = 7)`,
+		},
+		{
+			name:        "SchemaQualified",
+			table:       "auth.sessions",
+			filter:      map[string]interface{}{"id__in": []int{1, 2}},
+			expectedSQL: `DELETE FROM "auth"."sessions" AS "sessions" WHERE ("sessions"."id" IN (1, 2))`,
+		},
+		{name: "InvalidColumn", table: "sessions", filter: map[string]interface{}{"1=1; --": 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := deleteQuery(newTestStore(t).db.NewDelete(), tt.table, tt.filter)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSQL, q.String())
+		})
+	}
+}
+
+func TestSingleRowWritesRequireFilter(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	_, err := s.Update(ctx, "events", nil, &pageModel{Name: "x"})
+	assert.ErrorContains(t, err, "filter cannot be empty")
+	_, err = s.Delete(ctx, "events", map[string]interface{}{})
+	assert.ErrorContains(t, err, "filter cannot be empty")
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/lib/pq"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+)
+
+// keyDetailPattern extracts the columns from a constraint detail such as
+// "Key (email)=(a@b.c) already exists."
+var keyDetailPattern = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// translate maps driver errors onto the datastore sentinels. The original
+// error stays reachable through errors.As/Is, e.g. for retryableCode.
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	var de *datastore.Error
+	if errors.As(err, &de) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return &datastore.Error{Kind: datastore.ErrNotFound, Err: err}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &datastore.Error{Kind: datastore.ErrTimeout, Err: err}
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	var kind error
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		kind = datastore.ErrDuplicate
+	case "23503": // foreign_key_violation
+		kind = datastore.ErrForeignKey
+	case "23514": // check_violation
+		kind = datastore.ErrCheckViolation
+	case "57014", "55P03": // query_canceled (statement_timeout), lock_not_available (lock_timeout)
+		kind = datastore.ErrTimeout
+	default:
+		return err
+	}
+
+	column := pqErr.Column
+	if m := keyDetailPattern.FindStringSubmatch(pqErr.Detail); column == "" && m != nil {
+		column = m[1]
+	}
+	return &datastore.Error{
+		Kind:       kind,
+		Table:      pqErr.Table,
+		Constraint: pqErr.Constraint,
+		Column:     column,
+		Detail:     pqErr.Detail,
+		Err:        err,
+	}
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+		want datastore.Error
+	}{
+		{
+			name: "NoRows",
+			err:  sql.ErrNoRows,
+			kind: datastore.ErrNotFound,
+		},
+		{
+			name: "Deadline",
+			err:  fmt.Errorf("query: %w", context.DeadlineExceeded),
+			kind: datastore.ErrTimeout,
+		},
+		{
+			name: "UniqueViolation",
+			err:  &pq.Error{Code: "23505", Table: "users", Constraint: "users_email_key", Detail: "Key (email)=(a@b.c) already exists."},
+			kind: datastore.ErrDuplicate,
+			want: datastore.Error{Table: "users", Constraint: "users_email_key", Column: "email", Detail: "Key (email)=(a@b.c) already exists."},
+		},
+		{
+			name: "ForeignKeyViolation",
+			err:  &pq.Error{Code: "23503", Table: "sessions", Constraint: "sessions_user_id_fkey", Detail: "Key (user_id)=(42) is not present in table \"users\"."},
+			kind: datastore.ErrForeignKey,
+			want: datastore.Error{Table: "sessions", Constraint: "sessions_user_id_fkey", Column: "user_id", Detail: "Key (user_id)=(42) is not present in table \"users\"."},
+		},
+		{
+			name: "CheckViolation",
+			err:  &pq.Error{Code: "23514", Table: "orders", Constraint: "orders_amount_check", Column: "amount"},
+			kind: datastore.ErrCheckViolation,
+			want: datastore.Error{Table: "orders", Constraint: "orders_amount_check", Column: "amount"},
+		},
+		{
+			name: "StatementTimeout",
+			err:  &pq.Error{Code: "57014"},
+			kind: datastore.ErrTimeout,
+		},
+		{
+			name: "LockTimeout",
+			err:  &pq.Error{Code: "55P03"},
+			kind: datastore.ErrTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translate(tt.err)
+			assert.ErrorIs(t, got, tt.kind)
+			assert.ErrorIs(t, got, tt.err, "the driver error must stay reachable")
+
+			var de *datastore.Error
+			require.True(t, errors.As(got, &de))
+			assert.Equal(t, tt.want.Table, de.Table)
+			assert.Equal(t, tt.want.Constraint, de.Constraint)
+			assert.Equal(t, tt.want.Column, de.Column)
+			assert.Equal(t, tt.want.Detail, de.Detail)
+		})
+	}
+}
+
+func TestTranslatePassesThrough(t *testing.T) {
+	assert.NoError(t, translate(nil))
+
+	other := &pq.Error{Code: "42P01"}
+	assert.Same(t, other, translate(other))
+
+	plain := errors.New("boom")
+	assert.Same(t, plain, translate(plain))
+
+	translated := translate(sql.ErrNoRows)
+	assert.Same(t, translated, translate(translated))
+}
+
+func TestTranslateKeepsRetryable(t *testing.T) {
+	_, ok := retryableCode(translate(&pq.Error{Code: sqlStateSerializationFailure}))
+	assert.True(t, ok)
+}
@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// suffixOperators maps a "column__op" filter key suffix to its operator
+var suffixOperators = map[string]string{
+	"__is_null":     "is_null",
+	"__is_not_null": "is_not_null",
+	"__ne":          "!=",
+	"__gt":          ">",
+	"__gte":         ">=",
+	"__lt":          "<",
+	"__lte":         "<=",
+	"__like":        "like",
+	"__ilike":       "ilike",
+	"__in":          "in",
+	"__not_in":      "not_in",
+	"__between":     "between",
+	"__contains":    "contains",
+}
+
+// infixOperators maps the operator of a "column op" filter key, e.g. "age >"
+var infixOperators = map[string]string{
+	"=":      "=",
+	"!=":     "!=",
+	"<>":     "!=",
+	">":      ">",
+	">=":     ">=",
+	"<":      "<",
+	"<=":     "<=",
+	"like":   "like",
+	"ilike":  "ilike",
+	"in":     "in",
+	"not in": "not_in",
+}
+
+// buildFilter renders a QueryOption.Filter into a WHERE fragment with bun
+// placeholders. Conditions are ANDed in key order so the SQL is deterministic.
+func buildFilter(filter map[string]interface{}, alias string) (string, []interface{}, error) {
+	if len(filter) == 0 {
+		return "", nil, nil
+	}
+
+	keys := make([]string, 0, len(filter))
+	for k := range filter {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var (
+		parts []string
+		args  []interface{}
+	)
+	for _, k := range keys {
+		part, partArgs, err := buildCondition(k, filter[k], alias)
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, part)
+		args = append(args, partArgs...)
+	}
+	return strings.Join(parts, " AND "), args, nil
+}
+
+func buildCondition(key string, value interface{}, alias string) (string, []interface{}, error) {
+	switch key {
+	case datastore.FilterAnd, datastore.FilterOr:
+		return buildGroup(key, value, alias)
+	case datastore.FilterNot:
+		sub, ok := value.(map[string]interface{})
+		if !ok {
+			return "", nil, fmt.Errorf("%s expects a filter map, got %T", key, value)
+		}
+		sql, args, err := buildFilter(sub, alias)
+		if err != nil {
+			return "", nil, err
+		}
+		if sql == "" {
+			return "FALSE", nil, nil
+		}
+		return "NOT (" + sql + ")", args, nil
+	}
+
+	col, op, err := parseFilterKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+	ident, err := qualifiedColumn(alias, col)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch op {
+	case "=":
+		if value == nil {
+			return ident + " IS NULL", nil, nil
+		}
+		return ident + " = ?", []interface{}{value}, nil
+	case "!=":
+		if value == nil {
+			return ident + " IS NOT NULL", nil, nil
+		}
+		return ident + " != ?", []interface{}{value}, nil
+	case "is_null":
+		return ident + " IS NULL", nil, nil
+	case "is_not_null":
+		return ident + " IS NOT NULL", nil, nil
+	case ">", ">=", "<", "<=":
+		return ident + " " + op + " ?", []interface{}{value}, nil
+	case "like":
+		return ident + " LIKE ?", []interface{}{value}, nil
+	case "ilike":
+		return ident + " ILIKE ?", []interface{}{value}, nil
+	case "in", "not_in":
+		n, ok := sliceLen(value)
+		if !ok {
+			return "", nil, fmt.Errorf("filter %q expects a slice, got %T", key, value)
+		}
+		// IN () is a syntax error; an empty set matches nothing (or everything when negated)
+		if n == 0 {
+			if op == "in" {
+				return "FALSE", nil, nil
+			}
+			return "TRUE", nil, nil
+		}
+		if op == "in" {
+			return ident + " IN (?)", []interface{}{bun.In(value)}, nil
+		}
+		return ident + " NOT IN (?)", []interface{}{bun.In(value)}, nil
+	case "between":
+		if n, ok := sliceLen(value); !ok || n != 2 {
+			return "", nil, fmt.Errorf("filter %q expects a two-element slice", key)
+		}
+		v := reflect.ValueOf(value)
+		return ident + " BETWEEN ? AND ?", []interface{}{v.Index(0).Interface(), v.Index(1).Interface()}, nil
+	case "contains":
+		return buildContains(key, ident, value)
+	}
+	return "", nil, fmt.Errorf("unsupported filter operator in %q", key)
+}
+
+func buildGroup(key string, value interface{}, alias string) (string, []interface{}, error) {
+	var filters []map[string]interface{}
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		filters = v
+	case map[string]interface{}:
+		filters = []map[string]interface{}{v}
+	default:
+		return "", nil, fmt.Errorf("%s expects a list of filter maps, got %T", key, value)
+	}
+
+	joiner, empty := " AND ", "TRUE"
+	if key == datastore.FilterOr {
+		joiner, empty = " OR ", "FALSE"
+	}
+
+	var (
+		parts []string
+		args  []interface{}
+	)
+	for _, f := range filters {
+		sql, fArgs, err := buildFilter(f, alias)
+		if err != nil {
+			return "", nil, err
+		}
+		if sql == "" {
+			sql = "TRUE"
+		}
+		parts = append(parts, "("+sql+")")
+		args = append(args, fArgs...)
+	}
+	if len(parts) == 0 {
+		return empty, nil, nil
+	}
+	return "(" + strings.Join(parts, joiner) + ")", args, nil
+}
+
+// buildContains renders containment: Postgres arrays for slices, JSONB for maps and structs
+func buildContains(key, ident string, value interface{}) (string, []interface{}, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		return ident + " @> ?", []interface{}{pgdialect.Array(value)}, nil
+	case reflect.Map, reflect.Struct:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("filter %q: %w", key, err)
+		}
+		return ident + " @> ?::jsonb", []interface{}{string(b)}, nil
+	}
+	return "", nil, fmt.Errorf("filter %q expects a slice, map or struct, got %T", key, value)
+}
+
+// parseFilterKey splits a filter key into column and operator. Both the
+// "column__op" and the "column op" forms are accepted.
+func parseFilterKey(key string) (string, string, error) {
+	if col, op, ok := strings.Cut(strings.TrimSpace(key), " "); ok {
+		op = strings.ToLower(strings.Join(strings.Fields(op), " "))
+		if mapped, ok := infixOperators[op]; ok {
+			return col, mapped, nil
+		}
+		return "", "", fmt.Errorf("unsupported filter operator %q in %q", op, key)
+	}
+	if suffix, ok := longestSuffix(key); ok {
+		if col := strings.TrimSuffix(key, suffix); col != "" {
+			return col, suffixOperators[suffix], nil
+		}
+	}
+	return key, "=", nil
+}
+
+// longestSuffix finds the operator suffix of key, preferring "__not_in" over "__in"
+func longestSuffix(key string) (string, bool) {
+	best := ""
+	for suffix := range suffixOperators {
+		if strings.HasSuffix(key, suffix) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+	return best, best != ""
+}
+
+// qualifiedColumn validates col and quotes it, qualified with alias unless col already names a table
+func qualifiedColumn(alias, col string) (string, error) {
+	table, name, qualified := strings.Cut(col, ".")
+	if !qualified {
+		table, name = alias, col
+	}
+	if !identifierPattern.MatchString(name) {
+		return "", fmt.Errorf("invalid column name %q", col)
+	}
+	if table == "" {
+		return `"` + name + `"`, nil
+	}
+	if !identifierPattern.MatchString(table) {
+		return "", fmt.Errorf("invalid table alias %q", table)
+	}
+	return `"` + table + `"."` + name + `"`, nil
+}
+
+func sliceLen(value interface{}) (int, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return 0, false
+	}
+	return v.Len(), true
+}
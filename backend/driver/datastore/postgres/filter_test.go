@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type filterModel struct {
+	bun.BaseModel `bun:"table:events,alias:e"`
+
+	ID int64 `bun:"id,pk"`
+}
+
+func TestBuildFilter(t *testing.T) {
+	const prefix = `SELECT 1 FROM "events" AS "e" WHERE `
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Equal",
+			filter: map[string]interface{}{"status": "active"},
+			want:   `("e"."status" = 'active')`,
+		},
+		{
+			name:   "EqualNil",
+			filter: map[string]interface{}{"deleted_at": nil},
+			want:   `("e"."deleted_at" IS NULL)`,
+		},
+		{
+			name:   "SortedKeys",
+			filter: map[string]interface{}{"b": 2, "a": 1},
+			want:   `("e"."a" = 1 AND "e"."b" = 2)`,
+		},
+		{
+			name: "Comparison",
+			filter: map[string]interface{}{
+				"age__gt":   18,
+				"age__lte":  65,
+				"score__lt": 10,
+				"rank__gte": 3,
+				"kind__ne":  "bot",
+			},
+			want: `("e"."age" > 18 AND "e"."age" <= 65 AND "e"."kind" != 'bot' AND "e"."rank" >= 3 AND "e"."score" < 10)`,
+		},
+		{
+			name:   "InfixOperator",
+			filter: map[string]interface{}{"age >": 30, "status not in": []string{"x"}},
+			want:   `("e"."age" > 30 AND "e"."status" NOT IN ('x'))`,
+		},
+		{
+			name:   "Like",
+			filter: map[string]interface{}{"name__like": "Jo%", "email__ilike": "%@EXAMPLE.com"},
+			want:   `("e"."email" ILIKE '%@EXAMPLE.com' AND "e"."name" LIKE 'Jo%')`,
+		},
+		{
+			name:   "InAndNotIn",
+			filter: map[string]interface{}{"id__in": []int{1, 2}, "id__not_in": []int{3}},
+			want:   `("e"."id" IN (1, 2) AND "e"."id" NOT IN (3))`,
+		},
+		{
+			name:   "EmptyIn",
+			filter: map[string]interface{}{"id__in": []int{}, "kind__not_in": []string{}},
+			want:   `(FALSE AND TRUE)`,
+		},
+		{
+			name:   "Between",
+			filter: map[string]interface{}{"created_at__between": []time.Time{day, day.Add(24 * time.Hour)}},
+			want:   `("e"."created_at" BETWEEN '2026-01-01 00:00:00+00:00' AND '2026-01-02 00:00:00+00:00')`,
+		},
+		{
+			name:   "ContainsArray",
+			filter: map[string]interface{}{"tags__contains": []string{"a", "b"}},
+			want:   `("e"."tags" @> '{"a","b"}')`,
+		},
+		{
+			name:   "ContainsJSONB",
+			filter: map[string]interface{}{"meta__contains": map[string]interface{}{"plan": "pro"}},
+			want:   `("e"."meta" @> '{"plan":"pro"}'::jsonb)`,
+		},
+		{
+			name:   "NullChecks",
+			filter: map[string]interface{}{"a__is_null": true, "b__is_not_null": true},
+			want:   `("e"."a" IS NULL AND "e"."b" IS NOT NULL)`,
+		},
+		{
+			name:   "QualifiedColumn",
+			filter: map[string]interface{}{"u.email": "a@b.c"},
+			want:   `("u"."email" = 'a@b.c')`,
+		},
+		{
+			name: "Groups",
+			filter: map[string]interface{}{
+				"status": "active",
+				datastore.FilterOr: []map[string]interface{}{
+					{"vip": true},
+					{"score__gte": 90, datastore.FilterNot: map[string]interface{}{"country__in": []string{"XX"}}},
+				},
+			},
+			want: `((("e"."vip" = TRUE) OR (NOT ("e"."country" IN ('XX')) AND "e"."score" >= 90)) AND "e"."status" = 'active')`,
+		},
+		{
+			name:   "EmptyOr",
+			filter: map[string]interface{}{datastore.FilterOr: []map[string]interface{}{}},
+			want:   `(FALSE)`,
+		},
+		{
+			name:    "InjectionInColumn",
+			filter:  map[string]interface{}{`id" = 1 OR "1`: 1},
+			wantErr: true,
+		},
+		{
+			name:    "InvalidAlias",
+			filter:  map[string]interface{}{"x;drop.id": 1},
+			wantErr: true,
+		},
+		{
+			name:    "UnknownInfixOperator",
+			filter:  map[string]interface{}{"age ~": 1},
+			wantErr: true,
+		},
+		{
+			name:    "InRequiresSlice",
+			filter:  map[string]interface{}{"id__in": 1},
+			wantErr: true,
+		},
+		{
+			name:    "BetweenRequiresPair",
+			filter:  map[string]interface{}{"id__between": []int{1}},
+			wantErr: true,
+		},
+		{
+			name:    "ContainsRequiresCollection",
+			filter:  map[string]interface{}{"tags__contains": "a"},
+			wantErr: true,
+		},
+		{
+			name:    "NotRequiresMap",
+			filter:  map[string]interface{}{datastore.FilterNot: []int{1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := render(t, func(db *bun.DB) (fmt.Stringer, error) {
+				where, args, err := buildFilter(tt.filter, "e")
+				return db.NewSelect().Model((*filterModel)(nil)).ColumnExpr("1").Where(where, args...), err
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, prefix+tt.want, got)
+		})
+	}
+}
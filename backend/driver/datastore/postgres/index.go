@@ -0,0 +1,364 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+)
+
+// maxIdentifierLen is Postgres' NAMEDATALEN - 1; longer names are silently truncated
+const maxIdentifierLen = 63
+
+// rebuildSuffix marks the index built next to an outdated one before the swap
+const rebuildSuffix = "_rebuild"
+
+var (
+	indexDefPattern = regexp.MustCompile(`^CREATE (UNIQUE )?INDEX \S+ ON \S+ USING btree \((.+?)\)(?: WHERE \((.+)\))?$`)
+	notNullPattern  = regexp.MustCompile(`("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*) IS NOT NULL`)
+)
+
+// indexSpec is the normalised form of a datastore.Index, or of an index read back from pg_indexes
+type indexSpec struct {
+	name    string
+	columns []string
+	desc    []bool
+	unique  bool
+	sparse  bool
+}
+
+// existingIndex is a row of pg_indexes for the target table
+type existingIndex struct {
+	Name  string `bun:"indexname"`
+	Def   string `bun:"indexdef"`
+	Valid bool   `bun:"indisvalid"`
+}
+
+// EnsureIndices creates the missing indices on table and rebuilds those whose
+// definition differs from pg_indexes. Indexes are built CONCURRENTLY so writes
+// are not blocked; CONCURRENTLY cannot run inside a transaction, so this always
+// uses the pool even when ctx carries one. A rebuilt index replaces the old one
+// only once it has been built, so the table is never left without it.
+func (s *Store) EnsureIndices(ctx context.Context, table string, indices []datastore.Index) (*datastore.IndexReport, error) {
+	specs, err := indexSpecs(table, indices)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.existingIndices(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &datastore.IndexReport{}
+	for _, spec := range specs {
+		cur, ok := existing[spec.name]
+		if ok && cur.Valid && spec.matches(cur.Def) {
+			report.Unchanged = append(report.Unchanged, spec.name)
+			continue
+		}
+		if ok {
+			if err = s.rebuildIndex(ctx, table, spec); err != nil {
+				return report, err
+			}
+			report.Recreated = append(report.Recreated, spec.name)
+			continue
+		}
+		if err = s.createIndex(ctx, table, spec); err != nil {
+			return report, err
+		}
+		report.Created = append(report.Created, spec.name)
+	}
+	return report, nil
+}
+
+func (s *Store) createIndex(ctx context.Context, table string, spec indexSpec) error {
+	query, err := createIndexSQL(table, spec)
+	if err != nil {
+		return err
+	}
+	if _, err = s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("create index %s: %w", spec.name, translate(err))
+	}
+	return nil
+}
+
+// rebuildIndex builds spec under a temporary name, then drops the outdated
+// index and renames the new one into place. A failed build leaves the old
+// index untouched.
+func (s *Store) rebuildIndex(ctx context.Context, table string, spec indexSpec) error {
+	tmp := spec
+	tmp.name = rebuildIndexName(spec.name)
+
+	// An interrupted earlier rebuild may have left an invalid index behind
+	if _, err := s.db.ExecContext(ctx, dropIndexSQL(table, tmp.name)); err != nil {
+		return fmt.Errorf("drop index %s: %w", tmp.name, translate(err))
+	}
+	if err := s.createIndex(ctx, table, tmp); err != nil {
+		// A failed CONCURRENTLY build still leaves an invalid index to clean up
+		_, _ = s.db.ExecContext(context.WithoutCancel(ctx), dropIndexSQL(table, tmp.name))
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, dropIndexSQL(table, spec.name)); err != nil {
+		return fmt.Errorf("drop index %s: %w", spec.name, translate(err))
+	}
+	if _, err := s.db.ExecContext(ctx, renameIndexSQL(table, tmp.name, spec.name)); err != nil {
+		return fmt.Errorf("rename index %s: %w", tmp.name, translate(err))
+	}
+	return nil
+}
+
+// DropIndices drops the indices of table that exist, identified by Name or by
+// the name EnsureIndices generates for their columns.
+func (s *Store) DropIndices(ctx context.Context, table string, indices []datastore.Index) (*datastore.IndexReport, error) {
+	existing, err := s.existingIndices(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &datastore.IndexReport{}
+	for _, idx := range indices {
+		name := idx.Name
+		if name == "" {
+			spec, err := indexSpecOf(table, idx)
+			if err != nil {
+				return report, err
+			}
+			name = spec.name
+		}
+		if _, ok := existing[name]; !ok {
+			continue
+		}
+		if _, err = s.db.ExecContext(ctx, dropIndexSQL(table, name)); err != nil {
+			return report, fmt.Errorf("drop index %s: %w", name, translate(err))
+		}
+		report.Dropped = append(report.Dropped, name)
+	}
+	return report, nil
+}
+
+func (s *Store) existingIndices(ctx context.Context, table string) (map[string]existingIndex, error) {
+	schemaName, tableName := splitTable(table)
+	var rows []existingIndex
+	err := s.db.NewRaw(`
+		SELECT i.indexname, i.indexdef, x.indisvalid
+		FROM pg_indexes i
+		JOIN pg_namespace n ON n.nspname = i.schemaname
+		JOIN pg_class c ON c.relname = i.indexname AND c.relnamespace = n.oid
+		JOIN pg_index x ON x.indexrelid = c.oid
+		WHERE i.schemaname = COALESCE(NULLIF(?, ''), current_schema()) AND i.tablename = ?`,
+		schemaName, tableName,
+	).Scan(ctx, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes of %s: %w", table, err)
+	}
+
+	existing := make(map[string]existingIndex, len(rows))
+	for _, r := range rows {
+		existing[r.Name] = r
+	}
+	return existing, nil
+}
+
+func indexSpecs(table string, indices []datastore.Index) ([]indexSpec, error) {
+	specs := make([]indexSpec, 0, len(indices))
+	seen := make(map[string]bool, len(indices))
+	for _, idx := range indices {
+		spec, err := indexSpecOf(table, idx)
+		if err != nil {
+			return nil, err
+		}
+		if seen[spec.name] {
+			return nil, fmt.Errorf("index %s: defined twice", spec.name)
+		}
+		seen[spec.name] = true
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// indexSpecOf normalises idx: Fields in order, then the legacy Keys alphabetically
+func indexSpecOf(table string, idx datastore.Index) (indexSpec, error) {
+	spec := indexSpec{unique: idx.Unique, sparse: idx.Sparse}
+	add := func(col string, desc bool) error {
+		if !identifierPattern.MatchString(col) {
+			return fmt.Errorf("index %s: invalid column %q", idx.Name, col)
+		}
+		for _, c := range spec.columns {
+			if c == col {
+				return fmt.Errorf("index %s: column %q listed twice", idx.Name, col)
+			}
+		}
+		spec.columns = append(spec.columns, col)
+		spec.desc = append(spec.desc, desc)
+		return nil
+	}
+
+	for _, f := range idx.Fields {
+		var desc bool
+		switch datastore.SortOrder(strings.ToLower(string(f.Order))) {
+		case "", datastore.Asc:
+		case datastore.Desc:
+			desc = true
+		default:
+			return spec, fmt.Errorf("index %s: invalid order %q", idx.Name, f.Order)
+		}
+		if err := add(f.Column, desc); err != nil {
+			return spec, err
+		}
+	}
+
+	keys := make([]string, 0, len(idx.Keys))
+	for k := range idx.Keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if d := idx.Keys[k]; d != 1 && d != -1 {
+			return spec, fmt.Errorf("index %s: direction of %q must be 1 or -1", idx.Name, k)
+		}
+		if err := add(k, idx.Keys[k] == -1); err != nil {
+			return spec, err
+		}
+	}
+	if len(spec.columns) == 0 {
+		return spec, fmt.Errorf("index %s: no columns", idx.Name)
+	}
+
+	spec.name = idx.Name
+	if spec.name == "" {
+		spec.name = defaultIndexName(table, spec)
+	}
+	if !identifierPattern.MatchString(spec.name) || len(spec.name) > maxIdentifierLen {
+		return spec, fmt.Errorf("invalid index name %q", spec.name)
+	}
+	return spec, nil
+}
+
+// defaultIndexName follows Postgres' own convention, e.g. users_email_key or
+// sessions_user_id_created_at_idx, shortened with a hash when too long
+func defaultIndexName(table string, spec indexSpec) string {
+	_, tableName := splitTable(table)
+	suffix := "_idx"
+	if spec.unique {
+		suffix = "_key"
+	}
+	name := tableName + "_" + strings.Join(spec.columns, "_") + suffix
+	if len(name) <= maxIdentifierLen {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:4])
+	return name[:maxIdentifierLen-len(suffix)-len(hash)-1] + "_" + hash + suffix
+}
+
+func createIndexSQL(table string, spec indexSpec) (string, error) {
+	quoted, err := quoteTable(table)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if spec.unique {
+		b.WriteString("UNIQUE ")
+	}
+	fmt.Fprintf(&b, "INDEX CONCURRENTLY IF NOT EXISTS %s ON %s (", quoteIdent(spec.name), quoted)
+	for i, col := range spec.columns {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(quoteIdent(col))
+		if spec.desc[i] {
+			b.WriteString(" DESC")
+		}
+	}
+	b.WriteString(")")
+	if spec.sparse {
+		conds := make([]string, len(spec.columns))
+		for i, col := range spec.columns {
+			conds[i] = quoteIdent(col) + " IS NOT NULL"
+		}
+		b.WriteString(" WHERE " + strings.Join(conds, " AND "))
+	}
+	return b.String(), nil
+}
+
+func dropIndexSQL(table, name string) string {
+	return "DROP INDEX CONCURRENTLY IF EXISTS " + qualifiedIndex(table, name)
+}
+
+func renameIndexSQL(table, from, to string) string {
+	return "ALTER INDEX " + qualifiedIndex(table, from) + " RENAME TO " + quoteIdent(to)
+}
+
+// qualifiedIndex quotes name, qualified with the schema of table when it has one
+func qualifiedIndex(table, name string) string {
+	schemaName, _ := splitTable(table)
+	if schemaName != "" {
+		return quoteIdent(schemaName) + "." + quoteIdent(name)
+	}
+	return quoteIdent(name)
+}
+
+// rebuildIndexName is the temporary name a rebuild of index name is built under
+func rebuildIndexName(name string) string {
+	if len(name)+len(rebuildSuffix) > maxIdentifierLen {
+		name = name[:maxIdentifierLen-len(rebuildSuffix)]
+	}
+	return name + rebuildSuffix
+}
+
+// matches reports whether def, an indexdef from pg_indexes, builds the same index as spec
+func (spec indexSpec) matches(def string) bool {
+	m := indexDefPattern.FindStringSubmatch(def)
+	if m == nil || (m[1] != "") != spec.unique || (m[3] != "") != spec.sparse {
+		return false
+	}
+
+	cols := strings.Split(m[2], ", ")
+	if len(cols) != len(spec.columns) {
+		return false
+	}
+	for i, c := range cols {
+		c, desc := strings.CutSuffix(c, " DESC")
+		if unquoteIdent(c) != spec.columns[i] || desc != spec.desc[i] {
+			return false
+		}
+	}
+
+	if spec.sparse {
+		found := notNullPattern.FindAllStringSubmatch(m[3], -1)
+		if len(found) != len(spec.columns) {
+			return false
+		}
+		want := make(map[string]bool, len(spec.columns))
+		for _, c := range spec.columns {
+			want[c] = true
+		}
+		for _, f := range found {
+			if !want[unquoteIdent(f[1])] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func splitTable(table string) (string, string) {
+	if schemaName, name, ok := strings.Cut(table, "."); ok {
+		return schemaName, name
+	}
+	return "", table
+}
+
+func unquoteIdent(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
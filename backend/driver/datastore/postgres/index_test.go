@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateIndexSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		index   datastore.Index
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Single",
+			table: "users",
+			index: datastore.Index{Fields: []datastore.IndexField{{Column: "email"}}, Unique: true},
+			want:  `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS "users_email_key" ON "users" ("email")`,
+		},
+		{
+			name:  "OrderedFields",
+			table: "public.sessions",
+			index: datastore.Index{Fields: []datastore.IndexField{{Column: "user_id"}, {Column: "created_at", Order: datastore.Desc}}},
+			want:  `CREATE INDEX CONCURRENTLY IF NOT EXISTS "sessions_user_id_created_at_idx" ON "public"."sessions" ("user_id", "created_at" DESC)`,
+		},
+		{
+			name:  "LegacyKeysAfterFields",
+			table: "events",
+			index: datastore.Index{
+				Fields: []datastore.IndexField{{Column: "tenant_id"}},
+				Keys:   map[string]int{"starts_at": -1, "name": 1},
+				Name:   "events_listing",
+			},
+			want: `CREATE INDEX CONCURRENTLY IF NOT EXISTS "events_listing" ON "events" ("tenant_id", "name", "starts_at" DESC)`,
+		},
+		{
+			name:  "Sparse",
+			table: "users",
+			index: datastore.Index{Fields: []datastore.IndexField{{Column: "phone"}, {Column: "country"}}, Unique: true, Sparse: true},
+			want:  `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS "users_phone_country_key" ON "users" ("phone", "country") WHERE "phone" IS NOT NULL AND "country" IS NOT NULL`,
+		},
+		{name: "NoColumns", table: "users", index: datastore.Index{Name: "x"}, wantErr: true},
+		{name: "InvalidColumn", table: "users", index: datastore.Index{Fields: []datastore.IndexField{{Column: "lower(email)"}}}, wantErr: true},
+		{name: "InvalidDirection", table: "users", index: datastore.Index{Keys: map[string]int{"email": 2}}, wantErr: true},
+		{name: "InvalidOrder", table: "users", index: datastore.Index{Fields: []datastore.IndexField{{Column: "email", Order: "up"}}}, wantErr: true},
+		{name: "DuplicateColumn", table: "users", index: datastore.Index{Fields: []datastore.IndexField{{Column: "email"}}, Keys: map[string]int{"email": 1}}, wantErr: true},
+		{name: "InvalidName", table: "users", index: datastore.Index{Fields: []datastore.IndexField{{Column: "email"}}, Name: "x; DROP"}, wantErr: true},
+		{name: "InvalidTable", table: "users; --", index: datastore.Index{Fields: []datastore.IndexField{{Column: "email"}}, Name: "x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := indexSpecOf(tt.table, tt.index)
+			var got string
+			if err == nil {
+				got, err = createIndexSQL(tt.table, spec)
+			}
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIndexSpecsDuplicateName(t *testing.T) {
+	_, err := indexSpecs("users", []datastore.Index{
+		{Fields: []datastore.IndexField{{Column: "email"}}},
+		{Keys: map[string]int{"email": 1}},
+	})
+	assert.Error(t, err)
+}
+
+func TestDefaultIndexNameTruncates(t *testing.T) {
+	spec := indexSpec{columns: []string{strings.Repeat("a", 40), strings.Repeat("b", 40)}, desc: []bool{false, false}}
+	name := defaultIndexName("events", spec)
+	assert.Len(t, name, maxIdentifierLen)
+	assert.True(t, strings.HasSuffix(name, "_idx"))
+	assert.NotEqual(t, name, defaultIndexName("events", indexSpec{columns: []string{strings.Repeat("a", 40), strings.Repeat("c", 40)}}))
+}
+
+func TestIndexSpecMatches(t *testing.T) {
+	spec := indexSpec{name: "users_phone_created_at_key", columns: []string{"phone", "created_at"}, desc: []bool{false, true}, unique: true}
+	sparse := spec
+	sparse.sparse = true
+
+	tests := []struct {
+		name string
+		spec indexSpec
+		def  string
+		want bool
+	}{
+		{
+			name: "Same",
+			spec: spec,
+			def:  `CREATE UNIQUE INDEX users_phone_created_at_key ON public.users USING btree (phone, created_at DESC)`,
+			want: true,
+		},
+		{
+			name: "SameSparse",
+			spec: sparse,
+			def:  `CREATE UNIQUE INDEX users_phone_created_at_key ON public.users USING btree (phone, created_at DESC) WHERE ((phone IS NOT NULL) AND (created_at IS NOT NULL))`,
+			want: true,
+		},
+		{
+			name: "QuotedColumn",
+			spec: indexSpec{columns: []string{"Email"}, desc: []bool{false}},
+			def:  `CREATE INDEX users_email_idx ON public.users USING btree ("Email")`,
+			want: true,
+		},
+		{
+			name: "NotUnique",
+			spec: spec,
+			def:  `CREATE INDEX users_phone_created_at_key ON public.users USING btree (phone, created_at DESC)`,
+		},
+		{
+			name: "Direction",
+			spec: spec,
+			def:  `CREATE UNIQUE INDEX users_phone_created_at_key ON public.users USING btree (phone, created_at)`,
+		},
+		{
+			name: "ColumnOrder",
+			spec: spec,
+			def:  `CREATE UNIQUE INDEX users_phone_created_at_key ON public.users USING btree (created_at DESC, phone)`,
+		},
+		{
+			name: "UnexpectedPredicate",
+			spec: spec,
+			def:  `CREATE UNIQUE INDEX users_phone_created_at_key ON public.users USING btree (phone, created_at DESC) WHERE (phone IS NOT NULL)`,
+		},
+		{
+			name: "DifferentPredicate",
+			spec: sparse,
+			def:  `CREATE UNIQUE INDEX users_phone_created_at_key ON public.users USING btree (phone, created_at DESC) WHERE (deleted_at IS NULL)`,
+		},
+		{
+			name: "OtherMethod",
+			spec: spec,
+			def:  `CREATE UNIQUE INDEX users_phone_created_at_key ON public.users USING hash (phone)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.spec.matches(tt.def))
+		})
+	}
+}
+
+func TestDropIndexSQL(t *testing.T) {
+	assert.Equal(t, `DROP INDEX CONCURRENTLY IF EXISTS "users_email_key"`, dropIndexSQL("users", "users_email_key"))
+	assert.Equal(t, `DROP INDEX CONCURRENTLY IF EXISTS "auth"."users_email_key"`, dropIndexSQL("auth.users", "users_email_key"))
+}
+
+func TestRenameIndexSQL(t *testing.T) {
+	assert.Equal(t, `ALTER INDEX "users_email_key_rebuild" RENAME TO "users_email_key"`, renameIndexSQL("users", "users_email_key_rebuild", "users_email_key"))
+	assert.Equal(t, `ALTER INDEX "auth"."users_email_key_rebuild" RENAME TO "users_email_key"`, renameIndexSQL("auth.users", "users_email_key_rebuild", "users_email_key"))
+}
+
+func TestRebuildIndexName(t *testing.T) {
+	assert.Equal(t, "users_email_key_rebuild", rebuildIndexName("users_email_key"))
+
+	long := strings.Repeat("x", maxIdentifierLen)
+	got := rebuildIndexName(long)
+	assert.Len(t, got, maxIdentifierLen)
+	assert.True(t, strings.HasSuffix(got, rebuildSuffix))
+}
@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+)
+
+// joinTypes lists the accepted JoinOption.Type values; an empty type means INNER
+var joinTypes = map[string]string{
+	"":            "INNER JOIN",
+	"INNER":       "INNER JOIN",
+	"LEFT":        "LEFT JOIN",
+	"LEFT OUTER":  "LEFT OUTER JOIN",
+	"RIGHT":       "RIGHT JOIN",
+	"RIGHT OUTER": "RIGHT OUTER JOIN",
+	"FULL":        "FULL JOIN",
+	"FULL OUTER":  "FULL OUTER JOIN",
+	"CROSS":       "CROSS JOIN",
+}
+
+// aggregatePattern matches the left-hand side of a HAVING condition, e.g. "SUM(o.amount)"
+var aggregatePattern = regexp.MustCompile(`(?i)^(COUNT|SUM|AVG|MIN|MAX)\(\s*(DISTINCT\s+)?(\*|[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?)\s*\)$`)
+
+// applyJoins renders each JoinOption as a JOIN clause plus its selected columns
+func applyJoins(q *bun.SelectQuery, joins []datastore.JoinOption) error {
+	for i, j := range joins {
+		typ, ok := joinTypes[strings.ToUpper(strings.Join(strings.Fields(j.Type), " "))]
+		if !ok {
+			return fmt.Errorf("join %d: unsupported join type %q", i, j.Type)
+		}
+
+		table, alias, err := joinTarget(j)
+		if err != nil {
+			return fmt.Errorf("join %d: %w", i, err)
+		}
+
+		clause := fmt.Sprintf("%s %s AS %s", typ, table, quoteIdent(alias))
+		switch {
+		case typ == "CROSS JOIN" && j.On != "":
+			return fmt.Errorf("join %d: CROSS JOIN does not take an ON condition", i)
+		case typ != "CROSS JOIN" && j.On == "":
+			return fmt.Errorf("join %d: %s requires an ON condition", i, typ)
+		case j.On != "":
+			clause += " ON " + j.On
+		}
+		q.Join(clause, j.Args...)
+
+		for _, c := range j.Select {
+			col, err := selectColumn(alias, c)
+			if err != nil {
+				return fmt.Errorf("join %d: %w", i, err)
+			}
+			q.ColumnExpr(col)
+		}
+	}
+	return nil
+}
+
+// joinTarget returns the quoted table and the alias of a join. The alias comes
+// from JoinOption.Alias or, as in "associations a", from the table itself.
+func joinTarget(j datastore.JoinOption) (string, string, error) {
+	fields := strings.Fields(j.Table)
+	var table, alias string
+	switch len(fields) {
+	case 1:
+		table, alias = fields[0], j.Alias
+	case 2:
+		table, alias = fields[0], fields[1]
+		if j.Alias != "" && j.Alias != alias {
+			return "", "", fmt.Errorf("table %q conflicts with alias %q", j.Table, j.Alias)
+		}
+	case 3:
+		if !strings.EqualFold(fields[1], "AS") {
+			return "", "", fmt.Errorf("invalid table %q", j.Table)
+		}
+		table, alias = fields[0], fields[2]
+	default:
+		return "", "", fmt.Errorf("invalid table %q", j.Table)
+	}
+	if alias == "" {
+		alias = table
+	}
+
+	quoted, err := quoteTable(table)
+	if err != nil || !identifierPattern.MatchString(alias) {
+		return "", "", fmt.Errorf("invalid table %q", j.Table)
+	}
+	return quoted, alias, nil
+}
+
+// quoteTable validates and quotes a table name, optionally schema-qualified
+func quoteTable(table string) (string, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("invalid table %q", table)
+	}
+	for i, part := range parts {
+		if !identifierPattern.MatchString(part) {
+			return "", fmt.Errorf("invalid table %q", table)
+		}
+		parts[i] = quoteIdent(part)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// selectColumn renders "col" or "col AS name" qualified with alias
+func selectColumn(alias, c string) (string, error) {
+	col, as, hasAs := strings.Cut(c, " AS ")
+	if !hasAs {
+		col, as, hasAs = strings.Cut(c, " as ")
+	}
+	ident, err := qualifiedColumn(alias, strings.TrimSpace(col))
+	if err != nil {
+		return "", err
+	}
+	if !hasAs {
+		return ident, nil
+	}
+	as = strings.TrimSpace(as)
+	if !identifierPattern.MatchString(as) {
+		return "", fmt.Errorf("invalid column alias %q", as)
+	}
+	return ident + " AS " + quoteIdent(as), nil
+}
+
+// applyGroup adds GROUP BY columns, qualified with alias unless already qualified
+func applyGroup(q *bun.SelectQuery, group []string, alias string) error {
+	for _, g := range group {
+		col, err := qualifiedColumn(alias, g)
+		if err != nil {
+			return fmt.Errorf("group by: %w", err)
+		}
+		q.GroupExpr(col)
+	}
+	return nil
+}
+
+// applyHaving adds HAVING conditions. Keys are an aggregate or a column followed
+// by an operator, e.g. "SUM(o.amount) >"; values are bound as parameters.
+func applyHaving(q *bun.SelectQuery, having map[string]string, alias string) error {
+	keys := make([]string, 0, len(having))
+	for k := range having {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		expr, op, err := parseHavingKey(k, alias)
+		if err != nil {
+			return err
+		}
+		q.Having(expr+" "+op+" ?", having[k])
+	}
+	return nil
+}
+
+func parseHavingKey(key, alias string) (string, string, error) {
+	key = strings.TrimSpace(key)
+	i := strings.LastIndex(key, " ")
+	if i < 0 {
+		return "", "", fmt.Errorf("having %q: missing operator", key)
+	}
+	lhs, op := strings.TrimSpace(key[:i]), key[i+1:]
+	switch op {
+	case "=", "!=", "<>", ">", ">=", "<", "<=":
+	default:
+		return "", "", fmt.Errorf("having %q: unsupported operator %q", key, op)
+	}
+
+	if m := aggregatePattern.FindStringSubmatch(lhs); m != nil {
+		arg := m[3]
+		if arg != "*" {
+			col, err := qualifiedColumn(alias, arg)
+			if err != nil {
+				return "", "", fmt.Errorf("having %q: %w", key, err)
+			}
+			arg = col
+		}
+		return strings.ToUpper(m[1]) + "(" + strings.ToUpper(m[2]) + arg + ")", op, nil
+	}
+	col, err := qualifiedColumn(alias, lhs)
+	if err != nil {
+		return "", "", fmt.Errorf("having %q: expected an aggregate or column", key)
+	}
+	return col, op, nil
+}
+
+func quoteIdent(s string) string {
+	return `"` + s + `"`
+}
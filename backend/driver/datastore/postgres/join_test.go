@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestApplyJoins(t *testing.T) {
+	const prefix = `SELECT "e"."id"`
+
+	tests := []struct {
+		name    string
+		join    datastore.JoinOption
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "DefaultInner",
+			join: datastore.JoinOption{Table: "orders", Alias: "o", On: "o.event_id = e.id"},
+			want: ` FROM "events" AS "e" INNER JOIN "orders" AS "o" ON o.event_id = e.id`,
+		},
+		{
+			name: "InlineAlias",
+			join: datastore.JoinOption{Type: "left", Table: "orders o", On: "o.event_id = e.id"},
+			want: ` FROM "events" AS "e" LEFT JOIN "orders" AS "o" ON o.event_id = e.id`,
+		},
+		{
+			name: "FullOuterWithArgs",
+			join: datastore.JoinOption{Type: "FULL  OUTER", Table: "orders AS o", On: "o.event_id = e.id AND o.status = ?", Args: []interface{}{"paid"}},
+			want: ` FROM "events" AS "e" FULL OUTER JOIN "orders" AS "o" ON o.event_id = e.id AND o.status = 'paid'`,
+		},
+		{
+			name: "Cross",
+			join: datastore.JoinOption{Type: "CROSS", Table: "regions", Alias: "r"},
+			want: ` FROM "events" AS "e" CROSS JOIN "regions" AS "r"`,
+		},
+		{
+			name: "Select",
+			join: datastore.JoinOption{Type: "LEFT", Table: "orders", Alias: "o", On: "o.event_id = e.id", Select: []string{"amount", "status AS order_status"}},
+			want: `, "o"."amount", "o"."status" AS "order_status" FROM "events" AS "e" LEFT JOIN "orders" AS "o" ON o.event_id = e.id`,
+		},
+		{name: "UnsupportedType", join: datastore.JoinOption{Type: "LATERAL", Table: "orders", Alias: "o", On: "true"}, wantErr: true},
+		{name: "MissingOn", join: datastore.JoinOption{Type: "LEFT", Table: "orders", Alias: "o"}, wantErr: true},
+		{name: "CrossWithOn", join: datastore.JoinOption{Type: "CROSS", Table: "orders", Alias: "o", On: "true"}, wantErr: true},
+		{name: "InvalidTable", join: datastore.JoinOption{Table: "orders; DROP TABLE users", On: "true"}, wantErr: true},
+		{name: "AliasConflict", join: datastore.JoinOption{Table: "orders o", Alias: "x", On: "true"}, wantErr: true},
+		{name: "InvalidSelect", join: datastore.JoinOption{Table: "orders", Alias: "o", On: "true", Select: []string{"amount)"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := render(t, func(db *bun.DB) (fmt.Stringer, error) {
+				q := db.NewSelect().Model((*filterModel)(nil)).ColumnExpr(`"e"."id"`)
+				return q, applyJoins(q, []datastore.JoinOption{tt.join})
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, prefix+tt.want, got)
+		})
+	}
+}
+
+func TestApplyGroupHaving(t *testing.T) {
+	const prefix = `SELECT "e"."id" FROM "events" AS "e" `
+
+	tests := []struct {
+		name    string
+		opts    datastore.QueryOption
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Group",
+			opts: datastore.QueryOption{Group: []string{"id", "o.status"}},
+			want: `GROUP BY "e"."id", "o"."status"`,
+		},
+		{
+			name: "HavingAggregate",
+			opts: datastore.QueryOption{
+				Group:  []string{"id"},
+				Having: map[string]string{"sum(o.amount) >": "100", "COUNT(*) >=": "2"},
+			},
+			want: `GROUP BY "e"."id" HAVING (COUNT(*) >= '2') AND (SUM("o"."amount") > '100')`,
+		},
+		{
+			name: "HavingDistinct",
+			opts: datastore.QueryOption{Group: []string{"id"}, Having: map[string]string{"COUNT(DISTINCT o.id) =": "1"}},
+			want: `GROUP BY "e"."id" HAVING (COUNT(DISTINCT "o"."id") = '1')`,
+		},
+		{name: "InvalidGroup", opts: datastore.QueryOption{Group: []string{"id; --"}}, wantErr: true},
+		{name: "HavingMissingOperator", opts: datastore.QueryOption{Having: map[string]string{"COUNT(*)": "1"}}, wantErr: true},
+		{name: "HavingUnknownOperator", opts: datastore.QueryOption{Having: map[string]string{"COUNT(*) LIKE": "1"}}, wantErr: true},
+		{name: "HavingUnknownFunction", opts: datastore.QueryOption{Having: map[string]string{"pg_sleep(10) >": "1"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := render(t, func(db *bun.DB) (fmt.Stringer, error) {
+				q := db.NewSelect().Model((*filterModel)(nil)).ColumnExpr(`"e"."id"`)
+				if err := applyGroup(q, tt.opts.Group, "e"); err != nil {
+					return nil, err
+				}
+				return q, applyHaving(q, tt.opts.Having, "e")
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, prefix+tt.want, got)
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"github.com/rh-mithu/rizon/backend/config"
 	"log"
 	"log/slog"
@@ -10,9 +12,30 @@ import (
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/extra/bundebug"
+	"github.com/uptrace/bun/migrate"
 )
 
-func NewMigrator(cfg *config.Config, l *slog.Logger) *bun.DB {
+// migrationLockKey is the pg_advisory_lock key held while migrations run
+const migrationLockKey int64 = 0x72697a6f6e // "rizon"
+
+// The bun/migrate bookkeeping tables, pinned so run can look up a manual Lock
+const (
+	migrationsTable     = "bun_migrations"
+	migrationLocksTable = "bun_migration_locks"
+)
+
+// Migrator applies and rolls back schema migrations. Up and Down hold a
+// Postgres advisory lock, so replicas starting at the same time run the
+// migrations once and the others wait for them to finish.
+type Migrator struct {
+	db     *bun.DB
+	m      *migrate.Migrator
+	l      *slog.Logger
+	ownsDB bool
+}
+
+// NewMigrator opens a dedicated connection for running migrations
+func NewMigrator(cfg *config.Config, l *slog.Logger, migrations *migrate.Migrations) *Migrator {
 	dsn := cfg.SQLDatabaseURL
 	if dsn == "" {
 		log.Fatal("DATABASE_URL not found in environment variables")
@@ -23,11 +46,163 @@ func NewMigrator(cfg *config.Config, l *slog.Logger) *bun.DB {
 	}
 
 	db := bun.NewDB(sqlDB, pgdialect.New())
-	db.WithQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
+	if cfg.Env != "production" {
+		db.WithQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
+	}
 	if err = db.Ping(); err != nil {
 		l.Error("failed to ping Postgres", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 	l.Info("Connected to Postgres successfully")
-	return db
+	m := newMigrator(db, l, migrations)
+	m.ownsDB = true
+	return m
+}
+
+// Migrator returns a Migrator sharing the store's connection pool
+func (s *Store) Migrator(l *slog.Logger, migrations *migrate.Migrations) *Migrator {
+	return newMigrator(s.db, l, migrations)
+}
+
+// NewMigratorForFiles returns a Migrator without a connection, for CreateSQL and CreateGo only
+func NewMigratorForFiles(migrations *migrate.Migrations) *Migrator {
+	return newMigrator(nil, slog.Default(), migrations)
+}
+
+func newMigrator(db *bun.DB, l *slog.Logger, migrations *migrate.Migrations) *Migrator {
+	return &Migrator{
+		db: db,
+		m: migrate.NewMigrator(db, migrations,
+			migrate.WithTableName(migrationsTable),
+			migrate.WithLocksTableName(migrationLocksTable),
+			migrate.WithMarkAppliedOnSuccess(true),
+		),
+		l: l,
+	}
+}
+
+// Close closes the connection opened by NewMigrator; a Migrator from Store.Migrator leaves the pool open
+func (m *Migrator) Close() error {
+	if !m.ownsDB {
+		return nil
+	}
+	return m.db.Close()
+}
+
+// Up applies every pending migration as one group
+func (m *Migrator) Up(ctx context.Context) (*migrate.MigrationGroup, error) {
+	var group *migrate.MigrationGroup
+	err := m.run(ctx, func(ctx context.Context) error {
+		var err error
+		group, err = m.m.Migrate(ctx)
+		return err
+	})
+	if err != nil {
+		return group, fmt.Errorf("migrate up: %w", err)
+	}
+	if group.IsZero() {
+		m.l.Info("no new migrations to run")
+	} else {
+		m.l.Info("migrated", slog.String("group", group.String()))
+	}
+	return group, nil
+}
+
+// Down rolls back the last applied group
+func (m *Migrator) Down(ctx context.Context) (*migrate.MigrationGroup, error) {
+	var group *migrate.MigrationGroup
+	err := m.run(ctx, func(ctx context.Context) error {
+		var err error
+		group, err = m.m.Rollback(ctx)
+		return err
+	})
+	if err != nil {
+		return group, fmt.Errorf("migrate down: %w", err)
+	}
+	if group.IsZero() {
+		m.l.Info("no groups to roll back")
+	} else {
+		m.l.Info("rolled back", slog.String("group", group.String()))
+	}
+	return group, nil
+}
+
+// Status returns every migration, with GroupID and MigratedAt set on the applied ones
+func (m *Migrator) Status(ctx context.Context) (migrate.MigrationSlice, error) {
+	if err := m.m.Init(ctx); err != nil {
+		return nil, fmt.Errorf("migrate init: %w", err)
+	}
+	return m.m.MigrationsWithStatus(ctx)
+}
+
+// Lock blocks Up and Down until Unlock is called, e.g. during a maintenance window
+func (m *Migrator) Lock(ctx context.Context) error {
+	if err := m.m.Init(ctx); err != nil {
+		return fmt.Errorf("migrate init: %w", err)
+	}
+	return m.m.Lock(ctx)
+}
+
+// Unlock releases a Lock
+func (m *Migrator) Unlock(ctx context.Context) error {
+	if err := m.m.Init(ctx); err != nil {
+		return fmt.Errorf("migrate init: %w", err)
+	}
+	return m.m.Unlock(ctx)
+}
+
+// CreateSQL writes empty up and down SQL migration files next to the embedded ones
+func (m *Migrator) CreateSQL(ctx context.Context, name string, tx bool) ([]*migrate.MigrationFile, error) {
+	if tx {
+		return m.m.CreateTxSQLMigrations(ctx, name)
+	}
+	return m.m.CreateSQLMigrations(ctx, name)
+}
+
+// CreateGo writes an empty Go migration file next to the embedded ones
+func (m *Migrator) CreateGo(ctx context.Context, name string) (*migrate.MigrationFile, error) {
+	return m.m.CreateGoMigration(ctx, name)
+}
+
+// run calls fn while holding the advisory lock, which serialises concurrent
+// runs and is released by Postgres when a killed process drops its connection.
+// It only reads the migrations table lock, so a manual Lock stops the run but a
+// crashed run leaves nothing behind that blocks the next one.
+func (m *Migrator) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := m.m.Init(ctx); err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+
+	// Session-level advisory locks belong to a connection, so pin one
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock(?)", migrationLockKey); err != nil {
+		return fmt.Errorf("acquire advisory lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock(?)", migrationLockKey); err != nil {
+			m.l.Error("failed to release migration lock", slog.String("error", err.Error()))
+		}
+	}()
+
+	locked, err := manualLockQuery(m.db).Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("check migrations lock: %w", err)
+	}
+	if locked {
+		return fmt.Errorf("migrations are locked; run migrate unlock to release them")
+	}
+
+	return fn(ctx)
+}
+
+// manualLockQuery selects the row Lock inserts for the migrations table
+func manualLockQuery(db *bun.DB) *bun.SelectQuery {
+	return db.NewSelect().
+		TableExpr(quoteIdent(migrationLocksTable)).
+		Where("? = ?", bun.Ident("table_name"), migrationsTable)
 }
@@ -0,0 +1,18 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestManualLockQuery(t *testing.T) {
+	got, err := render(t, func(db *bun.DB) (fmt.Stringer, error) {
+		return manualLockQuery(db), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "bun_migration_locks" WHERE ("table_name" = 'bun_migrations')`, got)
+}
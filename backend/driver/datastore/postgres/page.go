@@ -0,0 +1,343 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+const (
+	cursorNext = "n"
+	cursorPrev = "p"
+)
+
+// cursor is the signed payload behind Page.Next and Page.Prev
+type cursor struct {
+	Dir    string        `json:"d"`
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// keysetField is one sort key of a keyset page, always a column of the model
+type keysetField struct {
+	name   string
+	column string
+	field  *schema.Field
+	desc   bool
+	// nullsFirst follows Postgres' default (NULLS LAST for ASC) unless set explicitly
+	nullsFirst bool
+}
+
+// cursorKey derives the cursor signing key from the config
+func cursorKey(cfg *config.Config) []byte {
+	if cfg.CursorSecret != "" {
+		return []byte(cfg.CursorSecret)
+	}
+	mac := hmac.New(sha256.New, []byte(cfg.JWTSecret))
+	mac.Write([]byte("datastore cursor"))
+	return mac.Sum(nil)
+}
+
+// FindPage fetches up to opts.Limit rows into dest, a pointer to a slice, using
+// keyset pagination on the query's sort keys plus the primary key. Unlike
+// Skip, a cursor neither slows down on deep pages nor skips or repeats rows
+// when rows are inserted or deleted between requests.
+func (s *Store) FindPage(ctx context.Context, table, alias string, dest interface{}, opts *datastore.QueryOption) (*datastore.Page, error) {
+	if dest == nil {
+		return nil, fmt.Errorf("dest cannot be nil")
+	}
+	if v := reflect.ValueOf(dest); v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("find page: dest must be a pointer to a slice, got %T", dest)
+	}
+	if opts == nil || opts.Limit <= 0 {
+		return nil, fmt.Errorf("find page: Limit is required")
+	}
+	if opts.Skip > 0 {
+		return nil, fmt.Errorf("find page: Skip is not supported; use Cursor")
+	}
+	if len(opts.Group) > 0 {
+		return nil, fmt.Errorf("find page: grouped queries cannot be paginated by cursor")
+	}
+
+	q := s.conn(ctx).NewSelect().Model(dest)
+	expr, alias, err := modelTable(q, table, alias)
+	if err != nil {
+		return nil, err
+	}
+	q = q.ModelTableExpr(expr)
+	keys, err := keysetFields(q, opts, alias)
+	if err != nil {
+		return nil, err
+	}
+	sig := sortSignature(keys)
+
+	var cur *cursor
+	if opts.Cursor != "" {
+		if cur, err = s.decodeCursor(opts.Cursor, sig, len(keys)); err != nil {
+			return nil, err
+		}
+	}
+	backward := cur != nil && cur.Dir == cursorPrev
+
+	o := *opts
+	o.Limit, o.Cursor, o.Sort = opts.Limit+1, "", nil
+	o.OrderBy = keysetOrder(keys, backward)
+	if err = applyQueryOptions(q, &o, alias); err != nil {
+		return nil, err
+	}
+	if cur != nil {
+		where, args := keysetPredicate(keys, cur.Values, backward)
+		q.Where(where, args...)
+	}
+	if err = q.Scan(ctx); err != nil {
+		return nil, translate(err)
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	more := int64(rows.Len()) > opts.Limit
+	if more {
+		rows.SetLen(int(opts.Limit))
+	}
+	if backward {
+		reverse(rows)
+	}
+
+	page := &datastore.Page{}
+	if n := rows.Len(); n > 0 {
+		if (backward && more) || (!backward && cur != nil) {
+			if page.Prev, err = s.encodeCursor(cursorPrev, sig, keys, rows.Index(0)); err != nil {
+				return nil, err
+			}
+		}
+		if backward || more {
+			if page.Next, err = s.encodeCursor(cursorNext, sig, keys, rows.Index(n-1)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opts.WithTotal {
+		c := *opts
+		c.Limit, c.Cursor, c.Sort, c.OrderBy = 0, "", nil, nil
+		total, err := s.Count(ctx, table, alias, dest, &c)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// keysetFields resolves the sort of opts to columns of the model; keyset
+// pagination cannot resume from expressions or joined columns.
+func keysetFields(q *bun.SelectQuery, opts *datastore.QueryOption, alias string) ([]keysetField, error) {
+	tm, ok := q.GetModel().(bun.TableModel)
+	if !ok {
+		return nil, fmt.Errorf("find page: dest must be a bun model slice")
+	}
+	sorts, err := orderBy(q, opts, alias)
+	if err != nil {
+		return nil, err
+	}
+	if len(sorts) == 0 {
+		for _, pk := range primaryKeys(q) {
+			sorts = append(sorts, datastore.SortField{Field: pk})
+		}
+	}
+
+	keys := make([]keysetField, 0, len(sorts))
+	for _, f := range sorts {
+		if f.Field == "" {
+			return nil, fmt.Errorf("find page: cannot paginate on expression %q", f.Expr)
+		}
+		name := f.Field
+		if a, col, ok := strings.Cut(name, "."); ok {
+			if a != alias {
+				return nil, fmt.Errorf("find page: cannot paginate on joined column %q", name)
+			}
+			name = col
+		}
+		field, ok := tm.Table().FieldMap[name]
+		if !ok {
+			return nil, fmt.Errorf("find page: %q is not a column of %s", f.Field, tm.Table().Name)
+		}
+		column, err := qualifiedColumn(alias, name)
+		if err != nil {
+			return nil, err
+		}
+
+		k := keysetField{name: name, column: column, field: field}
+		switch datastore.SortOrder(strings.ToLower(string(f.Order))) {
+		case "", datastore.Asc:
+		case datastore.Desc:
+			k.desc = true
+		default:
+			return nil, fmt.Errorf("find page: invalid sort order %q", f.Order)
+		}
+		switch datastore.NullsOrder(strings.ToLower(string(f.Nulls))) {
+		case "":
+			k.nullsFirst = k.desc
+		case datastore.NullsFirst:
+			k.nullsFirst = true
+		case datastore.NullsLast:
+		default:
+			return nil, fmt.Errorf("find page: invalid nulls order %q", f.Nulls)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("find page: %s has no sort or primary key", tm.Table().Name)
+	}
+	return keys, nil
+}
+
+// keysetOrder returns the ORDER BY for keys, reversed when paging backward
+func keysetOrder(keys []keysetField, backward bool) []datastore.SortField {
+	fields := make([]datastore.SortField, len(keys))
+	for i, k := range keys {
+		desc, nullsFirst := k.desc != backward, k.nullsFirst != backward
+		fields[i] = datastore.SortField{Field: k.name, Order: datastore.Asc, Nulls: datastore.NullsLast}
+		if desc {
+			fields[i].Order = datastore.Desc
+		}
+		if nullsFirst {
+			fields[i].Nulls = datastore.NullsFirst
+		}
+	}
+	return fields
+}
+
+// keysetPredicate selects the rows strictly after values in the order of keys,
+// or strictly before them when backward. For keys (a, b) it renders
+// (a after ?) OR (a = ? AND b after ?), where "after" accounts for the
+// direction and where NULLs sort.
+func keysetPredicate(keys []keysetField, values []interface{}, backward bool) (string, []interface{}) {
+	var (
+		ors  []string
+		args []interface{}
+	)
+	for i, k := range keys {
+		var (
+			ands    []string
+			andArgs []interface{}
+		)
+		for j := 0; j < i; j++ {
+			if values[j] == nil {
+				ands = append(ands, keys[j].column+" IS NULL")
+				continue
+			}
+			ands = append(ands, keys[j].column+" = ?")
+			andArgs = append(andArgs, values[j])
+		}
+
+		desc, nullsFirst := k.desc != backward, k.nullsFirst != backward
+		switch {
+		case values[i] == nil && nullsFirst:
+			ands = append(ands, k.column+" IS NOT NULL")
+		case values[i] == nil:
+			// nothing sorts after NULL when NULLs come last
+			continue
+		default:
+			op := ">"
+			if desc {
+				op = "<"
+			}
+			cond := k.column + " " + op + " ?"
+			if !nullsFirst && !k.field.IsPK && !k.field.NotNull {
+				cond = "(" + cond + " OR " + k.column + " IS NULL)"
+			}
+			ands = append(ands, cond)
+			andArgs = append(andArgs, values[i])
+		}
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		args = append(args, andArgs...)
+	}
+	if len(ors) == 0 {
+		return "FALSE", nil
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// sortSignature identifies the sort a cursor was issued for
+func sortSignature(keys []keysetField) string {
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s %t %t,", k.column, k.desc, k.nullsFirst)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func (s *Store) encodeCursor(dir, sig string, keys []keysetField, row reflect.Value) (string, error) {
+	row = reflect.Indirect(row)
+	c := cursor{Dir: dir, Sort: sig, Values: make([]interface{}, len(keys))}
+	for i, k := range keys {
+		v := k.field.Value(row).Interface()
+		if valuer, ok := v.(driver.Valuer); ok {
+			var err error
+			if v, err = valuer.Value(); err != nil {
+				return "", fmt.Errorf("cursor value %s: %w", k.field.Name, err)
+			}
+		}
+		c.Values[i] = v
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(s.sign(payload)), nil
+}
+
+func (s *Store) decodeCursor(raw, sig string, n int) (*cursor, error) {
+	enc := base64.RawURLEncoding
+	p, m, ok := strings.Cut(raw, ".")
+	if !ok {
+		return nil, datastore.ErrInvalidCursor
+	}
+	payload, err := enc.DecodeString(p)
+	if err != nil {
+		return nil, datastore.ErrInvalidCursor
+	}
+	mac, err := enc.DecodeString(m)
+	if err != nil || !hmac.Equal(mac, s.sign(payload)) {
+		return nil, datastore.ErrInvalidCursor
+	}
+
+	var c cursor
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err = dec.Decode(&c); err != nil {
+		return nil, datastore.ErrInvalidCursor
+	}
+	if c.Sort != sig || len(c.Values) != n || (c.Dir != cursorNext && c.Dir != cursorPrev) {
+		return nil, datastore.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func (s *Store) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.cursorKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func reverse(rows reflect.Value) {
+	swap := reflect.Swapper(rows.Interface())
+	for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
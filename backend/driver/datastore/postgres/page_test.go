@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type pageModel struct {
+	bun.BaseModel `bun:"table:events,alias:e"`
+
+	ID       int64        `bun:"id,pk"`
+	Name     string       `bun:"name"`
+	StartsAt sql.NullTime `bun:"starts_at"`
+}
+
+func pageQuery(t *testing.T) *bun.SelectQuery {
+	t.Helper()
+	var rows []pageModel
+	return testDB(t).NewSelect().Model(&rows).ColumnExpr("1")
+}
+
+func TestKeysetPredicate(t *testing.T) {
+	const prefix = `SELECT 1 FROM "events" AS "e" WHERE (`
+
+	tests := []struct {
+		name     string
+		opts     datastore.QueryOption
+		values   []interface{}
+		backward bool
+		want     string
+	}{
+		{
+			name:   "PrimaryKeyOnly",
+			opts:   datastore.QueryOption{},
+			values: []interface{}{7},
+			want:   `(("e"."id" > 7))`,
+		},
+		{
+			name:   "DescWithTiebreaker",
+			opts:   datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "name", Order: datastore.Desc}}},
+			values: []interface{}{"gala", 7},
+			want:   `(("e"."name" < 'gala') OR ("e"."name" = 'gala' AND "e"."id" > 7))`,
+		},
+		{
+			name:     "Backward",
+			opts:     datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "name"}}},
+			values:   []interface{}{"gala", 7},
+			backward: true,
+			want:     `(("e"."name" < 'gala') OR ("e"."name" = 'gala' AND "e"."id" < 7))`,
+		},
+		{
+			name:   "NullsLastAfterValue",
+			opts:   datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "starts_at"}}},
+			values: []interface{}{"2026-01-01T00:00:00Z", 7},
+			want:   `((("e"."starts_at" > '2026-01-01T00:00:00Z' OR "e"."starts_at" IS NULL)) OR ("e"."starts_at" = '2026-01-01T00:00:00Z' AND "e"."id" > 7))`,
+		},
+		{
+			name:   "NullsLastAfterNull",
+			opts:   datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "starts_at"}}},
+			values: []interface{}{nil, 7},
+			want:   `(("e"."starts_at" IS NULL AND "e"."id" > 7))`,
+		},
+		{
+			name:   "NullsFirstAfterNull",
+			opts:   datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "e.starts_at", Nulls: datastore.NullsFirst}}},
+			values: []interface{}{nil, 7},
+			want:   `(("e"."starts_at" IS NOT NULL) OR ("e"."starts_at" IS NULL AND "e"."id" > 7))`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := pageQuery(t)
+			keys, err := keysetFields(q, &tt.opts, "e")
+			require.NoError(t, err)
+			where, args := keysetPredicate(keys, tt.values, tt.backward)
+			assert.Equal(t, prefix+tt.want+")", q.Where(where, args...).String())
+		})
+	}
+}
+
+func TestKeysetOrder(t *testing.T) {
+	opts := &datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "starts_at", Order: datastore.Desc}}}
+	keys, err := keysetFields(pageQuery(t), opts, "e")
+	require.NoError(t, err)
+
+	assert.Equal(t, []datastore.SortField{
+		{Field: "starts_at", Order: datastore.Desc, Nulls: datastore.NullsFirst},
+		{Field: "id", Order: datastore.Asc, Nulls: datastore.NullsLast},
+	}, keysetOrder(keys, false))
+	assert.Equal(t, []datastore.SortField{
+		{Field: "starts_at", Order: datastore.Asc, Nulls: datastore.NullsLast},
+		{Field: "id", Order: datastore.Desc, Nulls: datastore.NullsFirst},
+	}, keysetOrder(keys, true))
+}
+
+func TestFindPageRejects(t *testing.T) {
+	var rows []pageModel
+	var row pageModel
+	tests := []struct {
+		name    string
+		dest    interface{}
+		opts    *datastore.QueryOption
+		wantErr string
+	}{
+		{name: "NilDest", dest: nil, opts: &datastore.QueryOption{Limit: 10}, wantErr: "dest cannot be nil"},
+		{name: "SliceValue", dest: rows, opts: &datastore.QueryOption{Limit: 10}, wantErr: "dest must be a pointer to a slice"},
+		{name: "PointerToStruct", dest: &row, opts: &datastore.QueryOption{Limit: 10}, wantErr: "dest must be a pointer to a slice"},
+		{name: "NoLimit", dest: &rows, opts: &datastore.QueryOption{}, wantErr: "Limit is required"},
+		{name: "Skip", dest: &rows, opts: &datastore.QueryOption{Limit: 10, Skip: 20}, wantErr: "Skip is not supported; use Cursor"},
+		{name: "Grouped", dest: &rows, opts: &datastore.QueryOption{Limit: 10, Group: []string{"name"}}, wantErr: "grouped queries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestStore(t).FindPage(context.Background(), "", "", tt.dest, tt.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestKeysetFieldsRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		sort datastore.SortField
+	}{
+		{name: "Expression", sort: datastore.SortField{Expr: "random()"}},
+		{name: "JoinedColumn", sort: datastore.SortField{Field: "o.amount"}},
+		{name: "UnknownColumn", sort: datastore.SortField{Field: "missing"}},
+		{name: "InvalidOrder", sort: datastore.SortField{Field: "name", Order: "up"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := keysetFields(pageQuery(t), &datastore.QueryOption{OrderBy: []datastore.SortField{tt.sort}}, "e")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	s := &Store{cursorKey: []byte("secret")}
+	keys, err := keysetFields(pageQuery(t), &datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "starts_at"}}}, "e")
+	require.NoError(t, err)
+	sig := sortSignature(keys)
+
+	row := pageModel{ID: 42, StartsAt: sql.NullTime{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true}}
+	raw, err := s.encodeCursor(cursorNext, sig, keys, reflect.ValueOf(row))
+	require.NoError(t, err)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		c, err := s.decodeCursor(raw, sig, len(keys))
+		require.NoError(t, err)
+		assert.Equal(t, cursorNext, c.Dir)
+		assert.Len(t, c.Values, 2)
+		assert.Equal(t, "2026-01-01T00:00:00Z", c.Values[0])
+		assert.Equal(t, "42", c.Values[1].(interface{ String() string }).String())
+	})
+
+	t.Run("NullValue", func(t *testing.T) {
+		raw, err := s.encodeCursor(cursorPrev, sig, keys, reflect.ValueOf(&pageModel{ID: 1}))
+		require.NoError(t, err)
+		c, err := s.decodeCursor(raw, sig, len(keys))
+		require.NoError(t, err)
+		assert.Nil(t, c.Values[0])
+	})
+
+	invalid := []struct {
+		name string
+		raw  string
+		sig  string
+		s    *Store
+	}{
+		{name: "Garbage", raw: "not-a-cursor", sig: sig, s: s},
+		{name: "Tampered", raw: "x" + raw, sig: sig, s: s},
+		{name: "OtherKey", raw: raw, sig: sig, s: &Store{cursorKey: []byte("other")}},
+		{name: "OtherSort", raw: raw, sig: "0000000000000000", s: s},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.s.decodeCursor(tt.raw, tt.sig, len(keys))
+			assert.ErrorIs(t, err, datastore.ErrInvalidCursor)
+		})
+	}
+}
+
+func TestReverse(t *testing.T) {
+	rows := []pageModel{{ID: 1}, {ID: 2}, {ID: 3}}
+	reverse(reflect.ValueOf(&rows).Elem())
+	assert.Equal(t, []int64{3, 2, 1}, []int64{rows[0].ID, rows[1].ID, rows[2].ID})
+}
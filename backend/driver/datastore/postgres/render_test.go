@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// testDB returns a bun DB over an unconnected Postgres handle, enough to build and format queries
+func testDB(t *testing.T) *bun.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("postgres", "")
+	require.NoError(t, err)
+	return bun.NewDB(sqlDB, pgdialect.New())
+}
+
+// render formats the query built on a test DB so placeholders show their final SQL.
+// An error from build is returned instead.
+func render(t *testing.T, build func(db *bun.DB) (fmt.Stringer, error)) (string, error) {
+	t.Helper()
+	q, err := build(testDB(t))
+	if err != nil {
+		return "", err
+	}
+	return q.String(), nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+)
+
+// applySort renders the ORDER BY clause built by orderBy
+func applySort(q *bun.SelectQuery, opts *datastore.QueryOption, alias string) error {
+	fields, err := orderBy(q, opts, alias)
+	if err != nil {
+		return err
+	}
+	for i, f := range fields {
+		expr, args, err := sortExpr(f, alias)
+		if err != nil {
+			return fmt.Errorf("sort %d: %w", i, err)
+		}
+		q.OrderExpr(expr, args...)
+	}
+	return nil
+}
+
+// orderBy returns OrderBy followed by the legacy Sort map (alphabetically) and,
+// when the query is not grouped, the model's primary key so equal rows keep a
+// stable order across pages.
+func orderBy(q *bun.SelectQuery, opts *datastore.QueryOption, alias string) ([]datastore.SortField, error) {
+	fields := append([]datastore.SortField(nil), opts.OrderBy...)
+
+	legacy := make([]string, 0, len(opts.Sort))
+	for k := range opts.Sort {
+		legacy = append(legacy, k)
+	}
+	sort.Strings(legacy)
+	for _, k := range legacy {
+		fields = append(fields, datastore.SortField{Field: k, Order: opts.Sort[k]})
+	}
+	if len(fields) == 0 || len(opts.Group) > 0 {
+		return fields, nil
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if f.Field != "" {
+			col, _ := qualifiedColumn(alias, f.Field)
+			seen[col] = true
+		}
+	}
+	for _, pk := range primaryKeys(q) {
+		col, err := qualifiedColumn(alias, pk)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[col] {
+			fields = append(fields, datastore.SortField{Field: pk, Order: datastore.Asc})
+		}
+	}
+	return fields, nil
+}
+
+// sortExpr renders one ORDER BY item with its direction and NULLS placement
+func sortExpr(f datastore.SortField, alias string) (string, []interface{}, error) {
+	var expr string
+	switch {
+	case f.Field != "" && f.Expr != "":
+		return "", nil, fmt.Errorf("set either Field or Expr, not both")
+	case f.Field != "":
+		col, err := qualifiedColumn(alias, f.Field)
+		if err != nil {
+			return "", nil, err
+		}
+		expr = col
+	case f.Expr != "":
+		expr = f.Expr
+	default:
+		return "", nil, fmt.Errorf("missing Field or Expr")
+	}
+
+	switch datastore.SortOrder(strings.ToLower(string(f.Order))) {
+	case "", datastore.Asc:
+		expr += " ASC"
+	case datastore.Desc:
+		expr += " DESC"
+	default:
+		return "", nil, fmt.Errorf("invalid sort order %q", f.Order)
+	}
+
+	switch datastore.NullsOrder(strings.ToLower(string(f.Nulls))) {
+	case "":
+	case datastore.NullsFirst:
+		expr += " NULLS FIRST"
+	case datastore.NullsLast:
+		expr += " NULLS LAST"
+	default:
+		return "", nil, fmt.Errorf("invalid nulls order %q", f.Nulls)
+	}
+	return expr, f.Args, nil
+}
+
+// primaryKeys returns the primary key columns of the query's model, if it has one
+func primaryKeys(q *bun.SelectQuery) []string {
+	tm, ok := q.GetModel().(bun.TableModel)
+	if !ok {
+		return nil
+	}
+	pks := make([]string, 0, len(tm.Table().PKs))
+	for _, f := range tm.Table().PKs {
+		pks = append(pks, f.Name)
+	}
+	return pks
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestApplySort(t *testing.T) {
+	const prefix = `SELECT 1 FROM "events" AS "e"`
+
+	tests := []struct {
+		name    string
+		opts    datastore.QueryOption
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "None",
+			opts: datastore.QueryOption{},
+			want: ``,
+		},
+		{
+			name: "OrderedWithTiebreaker",
+			opts: datastore.QueryOption{OrderBy: []datastore.SortField{
+				{Field: "starts_at", Order: datastore.Desc, Nulls: datastore.NullsLast},
+				{Field: "name"},
+			}},
+			want: ` ORDER BY "e"."starts_at" DESC NULLS LAST, "e"."name" ASC, "e"."id" ASC`,
+		},
+		{
+			name: "JoinedAliasAndExpr",
+			opts: datastore.QueryOption{OrderBy: []datastore.SortField{
+				{Field: "o.amount", Order: "DESC", Nulls: datastore.NullsFirst},
+				{Expr: "similarity(e.name, ?)", Args: []interface{}{"gala"}, Order: datastore.Desc},
+			}},
+			want: ` ORDER BY "o"."amount" DESC NULLS FIRST, similarity(e.name, 'gala') DESC, "e"."id" ASC`,
+		},
+		{
+			name: "PrimaryKeyNotRepeated",
+			opts: datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "id", Order: datastore.Desc}}},
+			want: ` ORDER BY "e"."id" DESC`,
+		},
+		{
+			name: "LegacyMapIsAlphabetical",
+			opts: datastore.QueryOption{Sort: map[string]datastore.SortOrder{"name": datastore.Asc, "created_at": datastore.Desc, "b": datastore.Asc}},
+			want: ` ORDER BY "e"."b" ASC, "e"."created_at" DESC, "e"."name" ASC, "e"."id" ASC`,
+		},
+		{
+			name: "LegacyMapAfterOrderBy",
+			opts: datastore.QueryOption{
+				OrderBy: []datastore.SortField{{Field: "starts_at"}},
+				Sort:    map[string]datastore.SortOrder{"name": datastore.Desc},
+			},
+			want: ` ORDER BY "e"."starts_at" ASC, "e"."name" DESC, "e"."id" ASC`,
+		},
+		{
+			name: "GroupedSkipsTiebreaker",
+			opts: datastore.QueryOption{Group: []string{"status"}, OrderBy: []datastore.SortField{{Field: "status"}}},
+			want: ` ORDER BY "e"."status" ASC`,
+		},
+		{name: "InvalidField", opts: datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "name; DROP TABLE users"}}}, wantErr: true},
+		{name: "InvalidLegacyField", opts: datastore.QueryOption{Sort: map[string]datastore.SortOrder{"1=1--": datastore.Asc}}, wantErr: true},
+		{name: "InvalidOrder", opts: datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "name", Order: "sideways"}}}, wantErr: true},
+		{name: "InvalidNulls", opts: datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "name", Nulls: "middle"}}}, wantErr: true},
+		{name: "FieldAndExpr", opts: datastore.QueryOption{OrderBy: []datastore.SortField{{Field: "name", Expr: "1"}}}, wantErr: true},
+		{name: "Empty", opts: datastore.QueryOption{OrderBy: []datastore.SortField{{Order: datastore.Asc}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := render(t, func(db *bun.DB) (fmt.Stringer, error) {
+				q := db.NewSelect().Model((*filterModel)(nil)).ColumnExpr("1")
+				return q, applySort(q, &tt.opts, "e")
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, prefix+tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+)
+
+// streamBatchSize is the number of rows a stream fetches from its cursor per round trip
+const streamBatchSize = 500
+
+// cursorSeq names the cursors of a process; a name only has to be unique per connection
+var cursorSeq atomic.Uint64
+
+// Stream declares a cursor for the FindMany query of opts and returns its rows
+func (s *Store) Stream(ctx context.Context, table, alias string, model interface{}, opts *datastore.QueryOption) (datastore.Rows, error) {
+	q, err := s.selectQuery(ctx, table, alias, model, opts)
+	if err != nil {
+		return nil, err
+	}
+	query, err := q.AppendQuery(s.db.QueryGen(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.declare(ctx, string(query))
+}
+
+// StreamRaw declares a cursor for query and returns its rows
+func (s *Store) StreamRaw(ctx context.Context, query string, args []interface{}) (datastore.Rows, error) {
+	return s.declare(ctx, s.db.QueryGen().FormatQuery(query, args...))
+}
+
+// declare opens a cursor for query in the transaction carried by ctx, or in a
+// read-only repeatable read one, so every batch reads the same snapshot
+func (s *Store) declare(ctx context.Context, query string) (datastore.Rows, error) {
+	r := &cursorRows{ctx: ctx, size: streamBatchSize}
+	name := fmt.Sprintf("stream_%d", cursorSeq.Add(1))
+
+	v, ok := s.txFrom(ctx)
+	if !ok {
+		t, err := s.BeginTx(ctx, &datastore.TxOptions{Isolation: datastore.RepeatableRead, ReadOnly: true})
+		if err != nil {
+			return nil, err
+		}
+		r.own = t
+		v, _ = s.txFrom(t.Context())
+	}
+	tx := v.tx.Tx
+
+	if _, err := tx.ExecContext(ctx, declareSQL(name, query)); err != nil {
+		if r.own != nil {
+			_ = r.own.Rollback(ctx)
+		}
+		return nil, translate(err)
+	}
+	r.cur = &sqlCursor{db: s.db, tx: tx, name: name}
+	return r, nil
+}
+
+func declareSQL(name, query string) string {
+	return "DECLARE " + quoteIdent(name) + " NO SCROLL CURSOR FOR " + query
+}
+
+// streamCursor is the server-side cursor a stream reads from
+type streamCursor interface {
+	// fetch reads the next n rows
+	fetch(ctx context.Context, n int) (rowBatch, error)
+	// close frees the cursor while its transaction goes on
+	close(ctx context.Context) error
+}
+
+// rowBatch is the result of one fetch
+type rowBatch interface {
+	Next() bool
+	Err() error
+	Close() error
+	scan(ctx context.Context, dest interface{}) error
+}
+
+// sqlCursor is a cursor declared in tx
+type sqlCursor struct {
+	db   *bun.DB
+	tx   *sql.Tx
+	name string
+}
+
+func (c *sqlCursor) fetch(ctx context.Context, n int) (rowBatch, error) {
+	rows, err := c.tx.QueryContext(ctx, fmt.Sprintf("FETCH FORWARD %d FROM %s", n, quoteIdent(c.name)))
+	if err != nil {
+		return nil, err
+	}
+	return &sqlBatch{Rows: rows, db: c.db}, nil
+}
+
+func (c *sqlCursor) close(ctx context.Context) error {
+	_, err := c.tx.ExecContext(ctx, "CLOSE "+quoteIdent(c.name))
+	return err
+}
+
+type sqlBatch struct {
+	*sql.Rows
+	db *bun.DB
+}
+
+func (b *sqlBatch) scan(ctx context.Context, dest interface{}) error {
+	return b.db.ScanRow(ctx, b.Rows, dest)
+}
+
+// cursorRows fetches the rows of a cursor size at a time
+type cursorRows struct {
+	ctx  context.Context
+	cur  streamCursor
+	own  datastore.Transaction // the transaction started for the stream, if any
+	size int
+
+	batch  rowBatch
+	n      int // rows read from batch
+	done   bool
+	err    error
+	closed bool
+}
+
+func (r *cursorRows) Next() bool {
+	if r.done || r.err != nil || r.closed {
+		return false
+	}
+	for {
+		if r.batch != nil {
+			if r.batch.Next() {
+				r.n++
+				return true
+			}
+			r.err = translate(r.batch.Err())
+			_ = r.batch.Close()
+			r.batch = nil
+			// A short batch means the cursor is exhausted; skip the empty FETCH
+			if r.err != nil || r.n < r.size {
+				r.done = true
+				return false
+			}
+		}
+
+		r.n = 0
+		r.batch, r.err = r.cur.fetch(r.ctx, r.size)
+		if r.err != nil {
+			r.err = translate(r.err)
+			return false
+		}
+	}
+}
+
+// Scan scans the current row into dest, a model or a pointer to a single column value
+func (r *cursorRows) Scan(dest interface{}) error {
+	if r.batch == nil {
+		return fmt.Errorf("stream: Scan called without a successful Next")
+	}
+	return translate(r.batch.scan(r.ctx, dest))
+}
+
+func (r *cursorRows) Err() error {
+	return r.err
+}
+
+// Close releases the cursor, and commits the stream's own transaction, or
+// rolls it back after a failure
+func (r *cursorRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	var err error
+	if r.batch != nil {
+		err = r.batch.Close()
+		r.batch = nil
+	}
+	if r.own != nil {
+		if r.err != nil {
+			return errors.Join(err, r.own.Rollback(r.ctx))
+		}
+		return errors.Join(err, translate(r.own.Commit(r.ctx)))
+	}
+	// The caller's transaction goes on; free the cursor's resources now
+	if r.err == nil {
+		err = errors.Join(err, translate(r.cur.close(r.ctx)))
+	}
+	return err
+}
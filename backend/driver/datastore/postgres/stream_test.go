@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeclareSQL(t *testing.T) {
+	s := newTestStore(t)
+	q, err := s.selectQuery(context.Background(), "events", "", (*pageModel)(nil), &datastore.QueryOption{
+		Filter:  map[string]interface{}{"name__like": "what?%"},
+		OrderBy: []datastore.SortField{{Field: "starts_at"}},
+	})
+	require.NoError(t, err)
+	query, err := q.AppendQuery(s.db.QueryGen(), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`DECLARE "stream_1" NO SCROLL CURSOR FOR SELECT "e".* FROM "events" AS "e" WHERE ("e"."name" LIKE 'what?%') ORDER BY "e"."starts_at" ASC, "e"."id" ASC`,
+		declareSQL("stream_1", string(query)),
+	)
+}
+
+// fakeCursor serves rows in batches, failing the fetch numbered failFetch and
+// making the batch of fetch batchErrAt report an error after its rows
+type fakeCursor struct {
+	rows       []int
+	failFetch  int
+	batchErrAt int
+
+	fetches int
+	batches []*fakeBatch
+	closed  bool
+}
+
+var errStream = errors.New("connection reset")
+
+func (c *fakeCursor) fetch(ctx context.Context, n int) (rowBatch, error) {
+	c.fetches++
+	if c.fetches == c.failFetch {
+		return nil, errStream
+	}
+	b := &fakeBatch{rows: c.rows[:min(n, len(c.rows))]}
+	c.rows = c.rows[len(b.rows):]
+	if c.fetches == c.batchErrAt {
+		b.err = errStream
+	}
+	c.batches = append(c.batches, b)
+	return b, nil
+}
+
+func (c *fakeCursor) close(ctx context.Context) error {
+	c.closed = true
+	return nil
+}
+
+type fakeBatch struct {
+	rows   []int
+	i      int
+	err    error
+	closed bool
+}
+
+func (b *fakeBatch) Next() bool {
+	if b.i == len(b.rows) {
+		return false
+	}
+	b.i++
+	return true
+}
+
+func (b *fakeBatch) Err() error   { return b.err }
+func (b *fakeBatch) Close() error { b.closed = true; return nil }
+
+func (b *fakeBatch) scan(ctx context.Context, dest interface{}) error {
+	*dest.(*int) = b.rows[b.i-1]
+	return nil
+}
+
+// fakeTx records how the stream's own transaction ended
+type fakeTx struct {
+	committed  bool
+	rolledBack bool
+}
+
+func (t *fakeTx) Context() context.Context           { return context.Background() }
+func (t *fakeTx) Commit(ctx context.Context) error   { t.committed = true; return nil }
+func (t *fakeTx) Rollback(ctx context.Context) error { t.rolledBack = true; return nil }
+
+func TestCursorRows(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        int
+		failFetch   int
+		batchErrAt  int
+		own         bool
+		wantRows    int
+		wantFetches int
+		wantErr     bool
+		wantEnd     string // "commit", "rollback", "close" (the cursor only) or "" (nothing)
+	}{
+		{name: "FullBatches", rows: 4, own: true, wantRows: 4, wantFetches: 3, wantEnd: "commit"},
+		{name: "ShortLastBatch", rows: 3, own: true, wantRows: 3, wantFetches: 2, wantEnd: "commit"},
+		{name: "Empty", rows: 0, own: true, wantRows: 0, wantFetches: 1, wantEnd: "commit"},
+		{name: "FetchFails", rows: 4, failFetch: 2, own: true, wantRows: 2, wantFetches: 2, wantErr: true, wantEnd: "rollback"},
+		{name: "BatchFails", rows: 4, batchErrAt: 2, own: true, wantRows: 4, wantFetches: 2, wantErr: true, wantEnd: "rollback"},
+		{name: "CallerTx", rows: 3, wantRows: 3, wantFetches: 2, wantEnd: "close"},
+		{name: "CallerTxFails", rows: 4, failFetch: 2, wantRows: 2, wantFetches: 2, wantErr: true, wantEnd: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur := &fakeCursor{failFetch: tt.failFetch, batchErrAt: tt.batchErrAt}
+			for i := range tt.rows {
+				cur.rows = append(cur.rows, i+1)
+			}
+			tx := &fakeTx{}
+			r := &cursorRows{ctx: context.Background(), cur: cur, size: 2}
+			if tt.own {
+				r.own = tx
+			}
+
+			var got []int
+			for r.Next() {
+				var v int
+				require.NoError(t, r.Scan(&v))
+				got = append(got, v)
+			}
+			assert.False(t, r.Next(), "an ended stream stays ended")
+			assert.Len(t, got, tt.wantRows)
+			for i, v := range got {
+				assert.Equal(t, i+1, v)
+			}
+			assert.Equal(t, tt.wantFetches, cur.fetches)
+			if tt.wantErr {
+				assert.ErrorIs(t, r.Err(), errStream)
+			} else {
+				assert.NoError(t, r.Err())
+			}
+			for _, b := range cur.batches {
+				assert.True(t, b.closed, "every batch is closed once read")
+			}
+
+			require.NoError(t, r.Close())
+			assert.Equal(t, tt.wantEnd == "commit", tx.committed)
+			assert.Equal(t, tt.wantEnd == "rollback", tx.rolledBack)
+			assert.Equal(t, tt.wantEnd == "close", cur.closed)
+		})
+	}
+}
+
+func TestCursorRowsCloseEarly(t *testing.T) {
+	cur := &fakeCursor{rows: []int{1, 2, 3, 4, 5}}
+	tx := &fakeTx{}
+	r := &cursorRows{ctx: context.Background(), cur: cur, own: tx, size: 2}
+
+	require.True(t, r.Next())
+	require.NoError(t, r.Close())
+	assert.True(t, cur.batches[0].closed, "the open batch is closed")
+	assert.True(t, tx.committed)
+	assert.Equal(t, 1, cur.fetches)
+
+	assert.False(t, r.Next())
+	assert.NoError(t, r.Close(), "Close is idempotent")
+}
+
+func TestCursorRowsScanWithoutNext(t *testing.T) {
+	r := &cursorRows{ctx: context.Background(), cur: &fakeCursor{}, size: 2}
+	assert.False(t, r.Next())
+	assert.ErrorContains(t, r.Scan(new(int)), "without a successful Next")
+}
@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+)
+
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// txRetries counts transaction retries by SQLSTATE, plus the transactions that
+// gave up because attempts ran out ("exhausted") or the deadline was too close
+// ("deadline"). Published through expvar; the API serves it to admins at
+// /admin/debug/vars.
+var txRetries = expvar.NewMap("datastore_tx_retries")
+
+type txKey struct{}
+
+// txValue is the transaction carried by a context, tagged with the pool it
+// belongs to so another Store never picks it up
+type txValue struct {
+	db   *bun.DB
+	tx   bun.Tx
+	opts datastore.TxOptions
+}
+
+type pgTx struct {
+	tx  bun.Tx
+	ctx context.Context
+}
+
+func (t *pgTx) Context() context.Context {
+	return t.ctx
+}
+
+// Commit commits the transaction, or releases the savepoint of a nested one
+func (t *pgTx) Commit(ctx context.Context) error {
+	return t.tx.Commit()
+}
+
+// Rollback rolls back the transaction, or to the savepoint of a nested one
+func (t *pgTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback()
+}
+
+// conn returns the transaction carried by ctx, or the pool
+func (s *Store) conn(ctx context.Context) bun.IDB {
+	if v, ok := s.txFrom(ctx); ok {
+		return v.tx
+	}
+	return s.db
+}
+
+func (s *Store) txFrom(ctx context.Context) (txValue, bool) {
+	v, ok := ctx.Value(txKey{}).(txValue)
+	if !ok || v.db != s.db {
+		return txValue{}, false
+	}
+	return v, true
+}
+
+func (s *Store) BeginTx(ctx context.Context, opts *datastore.TxOptions) (datastore.Transaction, error) {
+	tx, o, err := s.begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &pgTx{tx: tx, ctx: context.WithValue(ctx, txKey{}, txValue{db: s.db, tx: tx, opts: o})}, nil
+}
+
+// RunInTransaction runs fn in a transaction and, for an outermost transaction,
+// reruns it under the retry policy when Postgres aborts it with a serialization
+// failure or deadlock. fn must therefore be safe to call more than once. A
+// nested call never retries: the conflict aborted the outer transaction too.
+func (s *Store) RunInTransaction(ctx context.Context, opts *datastore.TxOptions, fn func(ctx context.Context, tx datastore.Transaction) error) error {
+	run := func() error { return s.runOnce(ctx, opts, fn) }
+	if _, nested := s.txFrom(ctx); nested {
+		return run()
+	}
+	policy := s.retry
+	if opts != nil && opts.Retry != nil {
+		policy = *opts.Retry
+	}
+	return retryTx(ctx, policy, run)
+}
+
+// retryTx calls run until it succeeds, fails with a non-retryable error, runs
+// out of attempts, or the next backoff would outlive the context deadline
+func retryTx(ctx context.Context, policy datastore.RetryPolicy, run func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := run()
+		code, retryable := retryableCode(err)
+		if err == nil || !retryable {
+			return err
+		}
+		if attempt >= policy.MaxAttempts {
+			txRetries.Add("exhausted", 1)
+			return err
+		}
+
+		delay := policy.Backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			txRetries.Add("deadline", 1)
+			return err
+		}
+		txRetries.Add(code, 1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+func (s *Store) runOnce(ctx context.Context, opts *datastore.TxOptions, fn func(ctx context.Context, tx datastore.Transaction) error) error {
+	t, err := s.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	done := false
+	defer func() {
+		if !done {
+			_ = t.Rollback(ctx)
+		}
+	}()
+
+	if err = fn(t.Context(), t); err != nil {
+		done = true
+		if rbErr := t.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("%w (rollback: %v)", err, rbErr)
+		}
+		return err
+	}
+	done = true
+	return translate(t.Commit(ctx))
+}
+
+// retryableCode reports whether err is a serialization failure (40001) or a
+// deadlock (40P01), which succeed when the transaction is simply run again
+func retryableCode(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return "", false
+	}
+	switch pqErr.Code {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return string(pqErr.Code), true
+	}
+	return "", false
+}
+
+// begin starts a transaction on the pool, or a savepoint on the transaction in ctx
+func (s *Store) begin(ctx context.Context, opts *datastore.TxOptions) (bun.Tx, datastore.TxOptions, error) {
+	var o datastore.TxOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	if outer, ok := s.txFrom(ctx); ok {
+		if (o.Isolation != "" || o.ReadOnly) && (o.Isolation != outer.opts.Isolation || o.ReadOnly != outer.opts.ReadOnly) {
+			return bun.Tx{}, o, fmt.Errorf("nested transaction cannot change options %+v of the outer one", outer.opts)
+		}
+		tx, err := outer.tx.BeginTx(ctx, nil)
+		if err != nil {
+			return bun.Tx{}, o, fmt.Errorf("savepoint: %w", err)
+		}
+		return tx, outer.opts, nil
+	}
+
+	sqlOpts, err := txOptions(o)
+	if err != nil {
+		return bun.Tx{}, o, err
+	}
+	tx, err := s.db.BeginTx(ctx, sqlOpts)
+	if err != nil {
+		return bun.Tx{}, o, fmt.Errorf("begin: %w", translate(err))
+	}
+	return tx, o, nil
+}
+
+func txOptions(o datastore.TxOptions) (*sql.TxOptions, error) {
+	opts := &sql.TxOptions{ReadOnly: o.ReadOnly}
+	switch o.Isolation {
+	case "":
+	case datastore.ReadCommitted:
+		opts.Isolation = sql.LevelReadCommitted
+	case datastore.RepeatableRead:
+		opts.Isolation = sql.LevelRepeatableRead
+	case datastore.Serializable:
+		opts.Isolation = sql.LevelSerializable
+	default:
+		return nil, fmt.Errorf("unsupported isolation level %q", o.Isolation)
+	}
+	return opts, nil
+}
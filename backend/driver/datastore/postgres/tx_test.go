@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	return &Store{db: testDB(t)}
+}
+
+func TestTxOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    datastore.TxOptions
+		want    sql.TxOptions
+		wantErr bool
+	}{
+		{name: "Default", opts: datastore.TxOptions{}, want: sql.TxOptions{}},
+		{name: "ReadCommitted", opts: datastore.TxOptions{Isolation: datastore.ReadCommitted}, want: sql.TxOptions{Isolation: sql.LevelReadCommitted}},
+		{name: "RepeatableReadOnly", opts: datastore.TxOptions{Isolation: datastore.RepeatableRead, ReadOnly: true}, want: sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}},
+		{name: "Serializable", opts: datastore.TxOptions{Isolation: datastore.Serializable}, want: sql.TxOptions{Isolation: sql.LevelSerializable}},
+		{name: "Unknown", opts: datastore.TxOptions{Isolation: "snapshot"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := txOptions(tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func TestConn(t *testing.T) {
+	s := newTestStore(t)
+	other := newTestStore(t)
+	tx := bun.Tx{}
+
+	assert.Same(t, s.db, s.conn(context.Background()))
+
+	ctx := context.WithValue(context.Background(), txKey{}, txValue{db: s.db, tx: tx})
+	assert.Equal(t, tx, s.conn(ctx))
+	assert.Same(t, other.db, other.conn(ctx), "a transaction of another store must not be used")
+}
+
+func TestBeginNestedOptions(t *testing.T) {
+	s := newTestStore(t)
+	outer := datastore.TxOptions{Isolation: datastore.Serializable}
+	ctx := context.WithValue(context.Background(), txKey{}, txValue{db: s.db, opts: outer})
+
+	_, err := s.BeginTx(ctx, &datastore.TxOptions{ReadOnly: true})
+	assert.Error(t, err)
+	_, err = s.BeginTx(ctx, &datastore.TxOptions{Isolation: datastore.ReadCommitted})
+	assert.Error(t, err)
+}
+
+func TestRetryTx(t *testing.T) {
+	serialization := &pq.Error{Code: sqlStateSerializationFailure}
+	deadlock := fmt.Errorf("commit: %w", &pq.Error{Code: sqlStateDeadlockDetected})
+	policy := datastore.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		ctx      func() (context.Context, context.CancelFunc)
+		errs     []error
+		wantErr  error
+		wantRuns int
+	}{
+		{
+			name:     "Success",
+			errs:     []error{nil},
+			wantRuns: 1,
+		},
+		{
+			name:     "RetriedThenSuccess",
+			errs:     []error{serialization, deadlock, nil},
+			wantRuns: 3,
+		},
+		{
+			name:     "Exhausted",
+			errs:     []error{serialization, serialization, serialization, nil},
+			wantErr:  serialization,
+			wantRuns: 3,
+		},
+		{
+			name:     "NotRetryable",
+			errs:     []error{&pq.Error{Code: "23505"}, nil},
+			wantErr:  &pq.Error{Code: "23505"},
+			wantRuns: 1,
+		},
+		{
+			name: "DeadlineTooClose",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), time.Now())
+			},
+			errs:     []error{serialization, nil},
+			wantErr:  serialization,
+			wantRuns: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if tt.ctx != nil {
+				ctx, cancel = tt.ctx()
+			}
+			defer cancel()
+
+			runs := 0
+			err := retryTx(ctx, policy, func() error {
+				err := tt.errs[runs]
+				runs++
+				return err
+			})
+			assert.Equal(t, tt.wantRuns, runs)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tt.wantErr.Error(), err.Error())
+			}
+		})
+	}
+}
+
+func TestRetryableCode(t *testing.T) {
+	code, ok := retryableCode(fmt.Errorf("wrapped: %w", &pq.Error{Code: "40P01"}))
+	assert.True(t, ok)
+	assert.Equal(t, "40P01", code)
+
+	_, ok = retryableCode(errors.New("40001"))
+	assert.False(t, ok)
+	_, ok = retryableCode(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// Upsert inserts data into table, resolving a conflict with an existing row as opts describes
+func (s *Store) Upsert(ctx context.Context, table string, data interface{}, opts *datastore.UpsertOption) (*datastore.WriteResult, error) {
+	if data == nil {
+		return nil, fmt.Errorf("data cannot be nil")
+	}
+	q, err := upsertQuery(s.conn(ctx).NewInsert().Model(data), table, opts)
+	if err != nil {
+		return nil, err
+	}
+	return writeResult(q.Exec(ctx))
+}
+
+// upsertQuery adds the ON CONFLICT clause of opts to q
+func upsertQuery(q *bun.InsertQuery, table string, opts *datastore.UpsertOption) (*bun.InsertQuery, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("upsert: options are required")
+	}
+	tm, ok := q.GetModel().(bun.TableModel)
+	if !ok {
+		return nil, fmt.Errorf("upsert: data must be a bun model")
+	}
+
+	quoted, err := quoteTable(table)
+	if err != nil {
+		return nil, err
+	}
+	// The alias lets Where tell the existing row apart from EXCLUDED
+	alias := tm.Table().Alias
+	if !identifierPattern.MatchString(alias) {
+		alias = "t"
+	}
+	q = q.ModelTableExpr(quoted + " AS " + quoteIdent(alias))
+
+	target, err := conflictTarget(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DoNothing {
+		if len(opts.Update) > 0 || len(opts.Where) > 0 {
+			return nil, fmt.Errorf("upsert: DoNothing cannot be combined with Update or Where")
+		}
+		q = q.On("CONFLICT" + target + " DO NOTHING")
+	} else {
+		if target == "" {
+			return nil, fmt.Errorf("upsert: Conflict or Constraint is required to update")
+		}
+		columns, err := updateColumns(tm.Table().Fields, opts)
+		if err != nil {
+			return nil, err
+		}
+		q = q.On("CONFLICT" + target + " DO UPDATE")
+		bumped := false
+		version := versionField(q)
+		for _, col := range columns {
+			q = q.Set(quoteIdent(col) + " = EXCLUDED." + quoteIdent(col))
+			bumped = bumped || (version != nil && col == version.Name)
+		}
+		if version != nil && !bumped {
+			q = q.Set(quoteIdent(version.Name) + " = " + quoteIdent(alias) + "." + quoteIdent(version.Name) + " + 1")
+		}
+
+		where, args, err := buildFilter(opts.Where, alias)
+		if err != nil {
+			return nil, fmt.Errorf("upsert: %w", err)
+		}
+		if where != "" {
+			q = q.Where(where, args...)
+		}
+	}
+
+	if opts.Returning {
+		q = q.Returning(returningColumns(q))
+	}
+	return q, nil
+}
+
+func conflictTarget(opts *datastore.UpsertOption) (string, error) {
+	switch {
+	case len(opts.Conflict) > 0 && opts.Constraint != "":
+		return "", fmt.Errorf("upsert: set either Conflict or Constraint, not both")
+	case opts.Constraint != "":
+		if !identifierPattern.MatchString(opts.Constraint) {
+			return "", fmt.Errorf("upsert: invalid constraint %q", opts.Constraint)
+		}
+		return " ON CONSTRAINT " + quoteIdent(opts.Constraint), nil
+	case len(opts.Conflict) > 0:
+		cols := make([]string, len(opts.Conflict))
+		for i, c := range opts.Conflict {
+			if !identifierPattern.MatchString(c) {
+				return "", fmt.Errorf("upsert: invalid conflict column %q", c)
+			}
+			cols[i] = quoteIdent(c)
+		}
+		return " (" + strings.Join(cols, ", ") + ")", nil
+	}
+	return "", nil
+}
+
+// updateColumns returns opts.Update, or every column of the model except the
+// conflict target, the primary key and the version, which is incremented instead
+func updateColumns(fields []*schema.Field, opts *datastore.UpsertOption) ([]string, error) {
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.Name] = true
+	}
+
+	if opts.Update != nil {
+		if len(opts.Update) == 0 {
+			return nil, fmt.Errorf("upsert: Update is empty; use DoNothing to keep existing rows")
+		}
+		for _, c := range opts.Update {
+			if !known[c] {
+				return nil, fmt.Errorf("upsert: %q is not a column of the model", c)
+			}
+		}
+		return opts.Update, nil
+	}
+
+	skip := make(map[string]bool, len(opts.Conflict))
+	for _, c := range opts.Conflict {
+		skip[c] = true
+	}
+	var columns []string
+	for _, f := range fields {
+		if !f.IsPK && !skip[f.Name] && !f.Tag.HasOption(versionTag) {
+			columns = append(columns, f.Name)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("upsert: no columns left to update; use DoNothing")
+	}
+	return columns, nil
+}
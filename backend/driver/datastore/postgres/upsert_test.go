@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertQuery(t *testing.T) {
+	const insert = `INSERT INTO "events" AS "e" ("id", "name", "starts_at") VALUES (1, 'launch', NULL) `
+
+	tests := []struct {
+		name        string
+		data        interface{}
+		opts        *datastore.UpsertOption
+		expectedSQL string
+		expectedErr string
+	}{
+		{
+			name:        "AllNonKeyColumns",
+			opts:        &datastore.UpsertOption{Conflict: []string{"id"}},
+			expectedSQL: insert + `ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "starts_at" = EXCLUDED."starts_at"`,
+		},
+		{
+			name:        "TargetExcludedFromUpdate",
+			opts:        &datastore.UpsertOption{Conflict: []string{"name"}},
+			expectedSQL: insert + `ON CONFLICT ("name") DO UPDATE SET "starts_at" = EXCLUDED."starts_at"`,
+		},
+		{
+			name:        "NothingLeftToUpdate",
+			opts:        &datastore.UpsertOption{Conflict: []string{"starts_at", "name"}},
+			expectedErr: "no columns left to update",
+		},
+		{
+			name:        "ExplicitColumns",
+			opts:        &datastore.UpsertOption{Constraint: "events_name_key", Update: []string{"starts_at"}},
+			expectedSQL: insert + `ON CONFLICT ON CONSTRAINT "events_name_key" DO UPDATE SET "starts_at" = EXCLUDED."starts_at"`,
+		},
+		{
+			name:        "DoNothingWithoutTarget",
+			opts:        &datastore.UpsertOption{DoNothing: true},
+			expectedSQL: insert + `ON CONFLICT DO NOTHING`,
+		},
+		{
+			name:        "DoNothingWithTarget",
+			opts:        &datastore.UpsertOption{Conflict: []string{"name"}, DoNothing: true},
+			expectedSQL: insert + `ON CONFLICT ("name") DO NOTHING`,
+		},
+		{
+			name: "ConditionalUpdateReturning",
+			opts: &datastore.UpsertOption{
+				Conflict:  []string{"id"},
+				Update:    []string{"name"},
+				Where:     map[string]interface{}{"name__ne": "locked"},
+				Returning: true,
+			},
+			expectedSQL: insert + `ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" WHERE ("e"."name" != 'locked') RETURNING "id", "name", "starts_at"`,
+		},
+		{
+			name:        "VersionIncremented",
+			data:        &versionedModel{ID: 3, Title: "draft", Version: 1},
+			opts:        &datastore.UpsertOption{Conflict: []string{"id"}},
+			expectedSQL: `INSERT INTO "documents" AS "d" ("id", "title", "version") VALUES (3, 'draft', 1) ON CONFLICT ("id") DO UPDATE SET "title" = EXCLUDED."title", "version" = "d"."version" + 1`,
+		},
+		{
+			name:        "MissingOptions",
+			expectedErr: "options are required",
+		},
+		{
+			name:        "UpdateWithoutTarget",
+			opts:        &datastore.UpsertOption{Update: []string{"name"}},
+			expectedErr: "Conflict or Constraint is required",
+		},
+		{
+			name:        "BothTargets",
+			opts:        &datastore.UpsertOption{Conflict: []string{"id"}, Constraint: "events_pkey"},
+			expectedErr: "not both",
+		},
+		{
+			name:        "InvalidConflictColumn",
+			opts:        &datastore.UpsertOption{Conflict: []string{"id; DROP"}},
+			expectedErr: "invalid conflict column",
+		},
+		{
+			name:        "UnknownUpdateColumn",
+			opts:        &datastore.UpsertOption{Conflict: []string{"id"}, Update: []string{"missing"}},
+			expectedErr: "not a column of the model",
+		},
+		{
+			name:        "DoNothingWithUpdate",
+			opts:        &datastore.UpsertOption{DoNothing: true, Update: []string{"name"}},
+			expectedErr: "cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, table := tt.data, "documents"
+			if data == nil {
+				data, table = &pageModel{ID: 1, Name: "launch"}, "events"
+			}
+			q, err := upsertQuery(newTestStore(t).db.NewInsert().Model(data), table, tt.opts)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSQL, q.String())
+		})
+	}
+}
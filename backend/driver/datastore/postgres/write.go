@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// versionTag is the bun tag option marking a model's optimistic concurrency column
+const versionTag = "version"
+
+func writeResult(res sql.Result, err error) (*datastore.WriteResult, error) {
+	if err != nil {
+		return nil, translate(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("rows affected: %w", err)
+	}
+	return &datastore.WriteResult{RowsAffected: n}, nil
+}
+
+// returningColumns lists the columns of the model of q for a RETURNING clause.
+// Naming them rather than using * keeps table columns the model doesn't map
+// from failing the scan.
+func returningColumns(q interface{ GetModel() bun.Model }) string {
+	table := modelSchema(q)
+	if table == nil {
+		return ""
+	}
+	cols := make([]string, len(table.Fields))
+	for i, f := range table.Fields {
+		cols[i] = string(f.SQLName)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// versionField returns the field of the model of q tagged as its version, if any
+func versionField(q interface{ GetModel() bun.Model }) *schema.Field {
+	table := modelSchema(q)
+	if table == nil {
+		return nil
+	}
+	for _, f := range table.Fields {
+		if f.Tag.HasOption(versionTag) {
+			return f
+		}
+	}
+	return nil
+}
+
+func modelSchema(q interface{ GetModel() bun.Model }) *schema.Table {
+	if tm, ok := q.GetModel().(bun.TableModel); ok {
+		return tm.Table()
+	}
+	return nil
+}
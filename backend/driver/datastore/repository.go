@@ -0,0 +1,231 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Repository is a typed view of a DataStore for a single bun model. The table,
+// alias and primary key column are read from the model's bun tags, e.g.
+//
+//	type User struct {
+//		bun.BaseModel `bun:"table:users,alias:u"`
+//		ID uuid.UUID  `bun:"id,pk"`
+//	}
+type Repository[T any] struct {
+	store DataStore
+	meta  ModelMeta
+}
+
+// ModelMeta describes how a model maps onto its table
+type ModelMeta struct {
+	Table string
+	Alias string
+	PK    string
+	pkIdx []int
+}
+
+// NewRepository builds a Repository for T, which must be a struct with a bun table tag and a pk column
+func NewRepository[T any](store DataStore) (*Repository[T], error) {
+	meta, err := MetaOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return &Repository[T]{store: store, meta: meta}, nil
+}
+
+// MustRepository is like NewRepository but panics on an invalid model. Intended for wiring code.
+func MustRepository[T any](store DataStore) *Repository[T] {
+	r, err := NewRepository[T](store)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Meta returns the table mapping of the repository's model
+func (r *Repository[T]) Meta() ModelMeta {
+	return r.meta
+}
+
+// Store returns the underlying DataStore
+func (r *Repository[T]) Store() DataStore {
+	return r.store
+}
+
+// Get fetches the row with the given primary key
+func (r *Repository[T]) Get(ctx context.Context, id interface{}) (*T, error) {
+	return r.FindOne(ctx, &QueryOption{Filter: map[string]interface{}{r.meta.PK: id}})
+}
+
+// FindOne fetches the first row matching opts
+func (r *Repository[T]) FindOne(ctx context.Context, opts *QueryOption) (*T, error) {
+	var entity T
+	if err := r.store.FindOne(ctx, r.meta.Table, r.meta.Alias, &entity, opts); err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// List fetches every row matching opts
+func (r *Repository[T]) List(ctx context.Context, opts *QueryOption) ([]T, error) {
+	var entities []T
+	if err := r.store.FindMany(ctx, r.meta.Table, r.meta.Alias, &entities, opts); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// Stream walks every row matching opts without loading them all at once
+func (r *Repository[T]) Stream(ctx context.Context, opts *QueryOption) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for entity, err := range Iterate[T](r.store.Stream(ctx, r.meta.Table, r.meta.Alias, (*T)(nil), opts)) {
+			if !yield(entity, err) {
+				return
+			}
+		}
+	}
+}
+
+// Page fetches up to opts.Limit rows after or before opts.Cursor
+func (r *Repository[T]) Page(ctx context.Context, opts *QueryOption) ([]T, *Page, error) {
+	var entities []T
+	page, err := r.store.FindPage(ctx, r.meta.Table, r.meta.Alias, &entities, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, page, nil
+}
+
+// Count counts the rows matching opts
+func (r *Repository[T]) Count(ctx context.Context, opts *QueryOption) (int, error) {
+	var entity T
+	return r.store.Count(ctx, r.meta.Table, r.meta.Alias, &entity, opts)
+}
+
+// Create inserts entity and fills in its database-generated columns
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	_, err := r.store.Insert(ctx, r.meta.Table, entity)
+	return err
+}
+
+// CreateMany inserts entities in chunks and fills in their database-generated columns
+func (r *Repository[T]) CreateMany(ctx context.Context, entities []T, opts *BulkOption) (*WriteResult, error) {
+	return r.store.InsertMany(ctx, r.meta.Table, entities, opts)
+}
+
+// Upsert inserts entity or resolves the conflict with an existing row as opts
+// describes. RowsAffected is 0 when the conflict left the existing row as is.
+func (r *Repository[T]) Upsert(ctx context.Context, entity *T, opts *UpsertOption) (*WriteResult, error) {
+	return r.store.Upsert(ctx, r.meta.Table, entity, opts)
+}
+
+// Update writes the non-zero fields of entity to the row with the same primary
+// key and reloads entity from it. It returns ErrNotFound when no such row
+// exists, or ErrConflict when a versioned entity is stale.
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	id, err := r.primaryKey(entity)
+	if err != nil {
+		return err
+	}
+	res, err := r.store.Update(ctx, r.meta.Table, map[string]interface{}{r.meta.PK: id}, entity)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes the row with the given primary key, or returns ErrNotFound when there is none
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	res, err := r.store.Delete(ctx, r.meta.Table, map[string]interface{}{r.meta.PK: id})
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository[T]) primaryKey(entity *T) (interface{}, error) {
+	if entity == nil {
+		return nil, fmt.Errorf("entity cannot be nil")
+	}
+	v := reflect.ValueOf(entity).Elem().FieldByIndex(r.meta.pkIdx)
+	if v.IsZero() {
+		return nil, fmt.Errorf("%s: primary key %q is not set", r.meta.Table, r.meta.PK)
+	}
+	return v.Interface(), nil
+}
+
+// MetaOf reads the table mapping of model T from its bun tags
+func MetaOf[T any]() (ModelMeta, error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return ModelMeta{}, fmt.Errorf("datastore: model %s must be a struct", typ)
+	}
+
+	meta := ModelMeta{Alias: snakeCase(typ.Name())}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, ok := field.Tag.Lookup("bun")
+		if !ok {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Name() == "BaseModel" {
+			for _, opt := range strings.Split(tag, ",") {
+				key, value, _ := strings.Cut(opt, ":")
+				switch key {
+				case "table":
+					meta.Table = value
+				case "alias":
+					meta.Alias = value
+				}
+			}
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		for _, opt := range opts[1:] {
+			if opt == "pk" && meta.PK == "" {
+				meta.PK = opts[0]
+				if meta.PK == "" {
+					meta.PK = snakeCase(field.Name)
+				}
+				meta.pkIdx = field.Index
+			}
+		}
+	}
+
+	if meta.Table == "" {
+		return ModelMeta{}, fmt.Errorf("datastore: model %s has no bun table tag", typ)
+	}
+	if meta.PK == "" {
+		return ModelMeta{}, fmt.Errorf("datastore: model %s has no pk column", typ)
+	}
+	return meta, nil
+}
+
+// snakeCase mirrors bun's default naming, e.g. "LoginToken" -> "login_token"
+func snakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type testReport struct {
+	bun.BaseModel `bun:"table:analytics_reports,alias:ar"`
+
+	ReportID int64  `bun:"report_id,pk,autoincrement"`
+	Title    string `bun:"title"`
+}
+
+type testNoAlias struct {
+	bun.BaseModel `bun:"table:daily_events"`
+
+	ID int64 `bun:",pk"`
+}
+
+type testNoTable struct {
+	ID int64 `bun:"id,pk"`
+}
+
+type testNoPK struct {
+	bun.BaseModel `bun:"table:no_pk"`
+
+	Name string `bun:"name"`
+}
+
+type call struct {
+	method string
+	table  string
+	alias  string
+	filter map[string]interface{}
+	dest   interface{}
+}
+
+type recordingStore struct {
+	DataStore
+	calls   []call
+	missing bool // writes match no row
+}
+
+func (s *recordingStore) result() *WriteResult {
+	if s.missing {
+		return &WriteResult{}
+	}
+	return &WriteResult{RowsAffected: 1}
+}
+
+func (s *recordingStore) FindOne(ctx context.Context, table, alias string, dest interface{}, opts *QueryOption) error {
+	s.calls = append(s.calls, call{method: "FindOne", table: table, alias: alias, filter: opts.Filter, dest: dest})
+	dest.(*testReport).Title = "found"
+	return nil
+}
+
+func (s *recordingStore) FindMany(ctx context.Context, table, alias string, dest interface{}, opts *QueryOption) error {
+	s.calls = append(s.calls, call{method: "FindMany", table: table, alias: alias, dest: dest})
+	*dest.(*[]testReport) = []testReport{{ReportID: 1}, {ReportID: 2}}
+	return nil
+}
+
+func (s *recordingStore) Insert(ctx context.Context, table string, data interface{}) (*WriteResult, error) {
+	s.calls = append(s.calls, call{method: "Insert", table: table, dest: data})
+	return s.result(), nil
+}
+
+func (s *recordingStore) Update(ctx context.Context, table string, filter map[string]interface{}, data interface{}) (*WriteResult, error) {
+	s.calls = append(s.calls, call{method: "Update", table: table, filter: filter, dest: data})
+	return s.result(), nil
+}
+
+func (s *recordingStore) Delete(ctx context.Context, table string, filter map[string]interface{}) (*WriteResult, error) {
+	s.calls = append(s.calls, call{method: "Delete", table: table, filter: filter})
+	return s.result(), nil
+}
+
+func TestMetaOf(t *testing.T) {
+	meta, err := MetaOf[testReport]()
+	require.NoError(t, err)
+	assert.Equal(t, "analytics_reports", meta.Table)
+	assert.Equal(t, "ar", meta.Alias)
+	assert.Equal(t, "report_id", meta.PK)
+
+	meta, err = MetaOf[testNoAlias]()
+	require.NoError(t, err)
+	assert.Equal(t, "test_no_alias", meta.Alias)
+	assert.Equal(t, "id", meta.PK)
+
+	_, err = MetaOf[testNoTable]()
+	assert.Error(t, err)
+	_, err = MetaOf[testNoPK]()
+	assert.Error(t, err)
+	_, err = MetaOf[*testReport]()
+	assert.Error(t, err)
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	store := &recordingStore{}
+	repo, err := NewRepository[testReport](store)
+	require.NoError(t, err)
+
+	got, err := repo.Get(ctx, int64(7))
+	require.NoError(t, err)
+	assert.Equal(t, "found", got.Title)
+
+	list, err := repo.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	report := &testReport{Title: "new"}
+	require.NoError(t, repo.Create(ctx, report))
+	assert.Error(t, repo.Update(ctx, report), "update requires a primary key")
+	report.ReportID = 7
+	require.NoError(t, repo.Update(ctx, report))
+	require.NoError(t, repo.Delete(ctx, int64(7)))
+
+	pk := map[string]interface{}{"report_id": int64(7)}
+	assert.Equal(t, []call{
+		{method: "FindOne", table: "analytics_reports", alias: "ar", filter: pk, dest: got},
+		{method: "FindMany", table: "analytics_reports", alias: "ar", dest: &list},
+		{method: "Insert", table: "analytics_reports", dest: report},
+		{method: "Update", table: "analytics_reports", filter: pk, dest: report},
+		{method: "Delete", table: "analytics_reports", filter: pk},
+	}, store.calls)
+}
+
+func TestRepositoryWriteMissingRow(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository[testReport](&recordingStore{missing: true})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, repo.Update(ctx, &testReport{ReportID: 7}), ErrNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, int64(7)), ErrNotFound)
+}
@@ -0,0 +1,30 @@
+package datastore
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy reruns a transaction that failed because of a conflict with a
+// concurrent one, such as a serialization failure or a deadlock.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // backoff cap before the second attempt, doubled for each later one
+	MaxDelay    time.Duration // upper bound of the backoff cap
+}
+
+// Backoff returns how long to wait after the given failed attempt (1-based).
+// The wait is drawn uniformly from [0, cap) so competing transactions spread out.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 || attempt < 1 {
+		return 0
+	}
+	limit := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || limit < p.MaxDelay); i++ {
+		limit *= 2
+	}
+	if p.MaxDelay > 0 && limit > p.MaxDelay {
+		limit = p.MaxDelay
+	}
+	return rand.N(limit)
+}
@@ -0,0 +1,32 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Millisecond, MaxDelay: 35 * time.Millisecond}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 1, max: 10 * time.Millisecond},
+		{attempt: 2, max: 20 * time.Millisecond},
+		{attempt: 3, max: 35 * time.Millisecond},
+		{attempt: 10, max: 35 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for range 100 {
+			d := p.Backoff(tt.attempt)
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			assert.Less(t, d, tt.max, "attempt %d", tt.attempt)
+		}
+	}
+
+	assert.Zero(t, RetryPolicy{}.Backoff(1))
+	assert.Zero(t, p.Backoff(0))
+}
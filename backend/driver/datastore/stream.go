@@ -0,0 +1,34 @@
+package datastore
+
+import (
+	"errors"
+	"iter"
+)
+
+// Iterate scans each row into a new T. It closes rows once the loop ends,
+// including when the caller breaks out early; an error ends the loop.
+//
+//	for user, err := range datastore.Iterate[User](store.StreamRaw(ctx, query, args)) {
+func Iterate[T any](rows Rows, err error) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for rows.Next() {
+			var v T
+			if err := rows.Scan(&v); err != nil {
+				yield(nil, errors.Join(err, rows.Close()))
+				return
+			}
+			if !yield(&v, nil) {
+				_ = rows.Close()
+				return
+			}
+		}
+		if err := errors.Join(rows.Err(), rows.Close()); err != nil {
+			yield(nil, err)
+		}
+	}
+}
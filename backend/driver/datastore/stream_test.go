@@ -0,0 +1,114 @@
+package datastore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceRows serves titles as testReport rows
+type sliceRows struct {
+	titles   []string
+	pos      int
+	scanErr  error
+	closeErr error
+	closed   int
+}
+
+func (r *sliceRows) Next() bool {
+	if r.pos >= len(r.titles) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *sliceRows) Scan(dest interface{}) error {
+	if r.scanErr != nil {
+		return r.scanErr
+	}
+	dest.(*testReport).Title = r.titles[r.pos-1]
+	return nil
+}
+
+func (r *sliceRows) Err() error { return nil }
+
+func (r *sliceRows) Close() error {
+	r.closed++
+	return r.closeErr
+}
+
+func TestIterate(t *testing.T) {
+	errScan := errors.New("scan failed")
+	errClose := errors.New("commit failed")
+
+	tests := []struct {
+		name           string
+		rows           *sliceRows
+		openErr        error
+		stopAfter      int
+		expectedTitles []string
+		expectedErr    error
+	}{
+		{
+			name:           "AllRows",
+			rows:           &sliceRows{titles: []string{"a", "b", "c"}},
+			expectedTitles: []string{"a", "b", "c"},
+		},
+		{
+			name:           "BreakEarly",
+			rows:           &sliceRows{titles: []string{"a", "b", "c"}},
+			stopAfter:      2,
+			expectedTitles: []string{"a", "b"},
+		},
+		{
+			name:        "ScanError",
+			rows:        &sliceRows{titles: []string{"a"}, scanErr: errScan},
+			expectedErr: errScan,
+		},
+		{
+			name:           "CloseError",
+			rows:           &sliceRows{titles: []string{"a"}, closeErr: errClose},
+			expectedTitles: []string{"a"},
+			expectedErr:    errClose,
+		},
+		{
+			name:        "OpenError",
+			openErr:     ErrTimeout,
+			expectedErr: ErrTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows Rows
+			if tt.rows != nil {
+				rows = tt.rows
+			}
+
+			var titles []string
+			var gotErr error
+			for report, err := range Iterate[testReport](rows, tt.openErr) {
+				if err != nil {
+					gotErr = err
+					continue
+				}
+				titles = append(titles, report.Title)
+				if len(titles) == tt.stopAfter {
+					break
+				}
+			}
+
+			assert.Equal(t, tt.expectedTitles, titles)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, gotErr, tt.expectedErr)
+			} else {
+				assert.NoError(t, gotErr)
+			}
+			if tt.rows != nil {
+				assert.Equal(t, 1, tt.rows.closed, "rows are closed exactly once")
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/rh-mithu/rizon/backend/config"
 	"github.com/rh-mithu/rizon/backend/driver/datastore/postgres"
 	"github.com/rh-mithu/rizon/backend/internal/delivery/rest"
+	"github.com/rh-mithu/rizon/backend/migrations"
 	"log"
 	"log/slog"
 	"net/http"
@@ -23,12 +24,20 @@ type App struct {
 }
 
 func New(ctx context.Context, cfg *config.Config, l *slog.Logger) (*App, error) {
-	handler := rest.ProvideHandler(cfg)
+	store := postgres.NewStore(cfg, l)
+	if cfg.MigrateOnStart {
+		if _, err := store.Migrator(l, migrations.Migrations).Up(ctx); err != nil {
+			return nil, err
+		}
+	}
+	handler, err := rest.ProvideHandler(cfg, l, store)
+	if err != nil {
+		return nil, err
+	}
 	server := &http.Server{
 		Addr:    ":" + cfg.ServicePort,
 		Handler: handler,
 	}
-	store := postgres.NewStore(cfg, l)
 	return &App{
 		cfg:    cfg,
 		server: server,
@@ -8,12 +8,23 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "userID"
 
+var (
+	ErrMissingAuthorization       = domain.NewError(domain.KindUnauthorized, "missing_authorization", "authorization header required")
+	ErrInvalidAuthorizationHeader = domain.NewError(domain.KindUnauthorized, "invalid_authorization_header", "invalid authorization header format")
+	ErrInvalidToken               = domain.NewError(domain.KindUnauthorized, "invalid_token", "invalid token")
+	ErrSessionRevoked             = domain.NewError(domain.KindUnauthorized, "session_revoked", "session has been revoked")
+	ErrSessionCheckFailed         = domain.NewError(domain.KindUnavailable, "session_check_failed", "unable to verify session")
+	ErrAuthenticationRequired     = domain.NewError(domain.KindUnauthorized, "authentication_required", "authentication required")
+)
+
 // GetUserID Context method to retrieve UserID
 func GetUserID(ctx context.Context) (uuid.UUID, error) {
 	id, ok := ctx.Value(UserIDKey).(uuid.UUID)
@@ -23,51 +34,62 @@ func GetUserID(ctx context.Context) (uuid.UUID, error) {
 	return id, nil
 }
 
-// AuthMiddleware verifies the JWT token from Supabase
-func AuthMiddleware(secret string) func(http.Handler) http.Handler {
+// AuthMiddleware verifies HS256 JWT tokens signed with secret (e.g. Supabase).
+// It fails when the secret is empty.
+func AuthMiddleware(secret string) (func(http.Handler) http.Handler, error) {
+	v, err := NewVerifier(VerifierConfig{Algorithms: []string{"HS256"}, Secret: secret})
+	if err != nil {
+		return nil, err
+	}
+	return Authenticate(v), nil
+}
+
+// Authenticate verifies the bearer token with v and stores the Principal in the request context
+func Authenticate(v *Verifier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "authorization header required", http.StatusUnauthorized)
+				response.FromError(w, r, ErrMissingAuthorization)
 				return
 			}
 
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "invalid authorization header format", http.StatusUnauthorized)
+				response.FromError(w, r, ErrInvalidAuthorizationHeader)
 				return
 			}
 
 			tokenString := parts[1]
 
 			// Parse and validate token
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				// Supabase uses HS256, just return the secret
-				return []byte(secret), nil
-			})
+			token, err := v.Parse(r.Context(), tokenString)
 
 			if err != nil || !token.Valid {
-				http.Error(w, "invalid token", http.StatusUnauthorized)
+				response.FromError(w, r, ErrInvalidToken)
 				return
 			}
 
 			claims, _ := token.Claims.(jwt.MapClaims)
 
-			sub, ok := claims["sub"].(string)
-			if !ok {
-				http.Error(w, "sub claim missing", http.StatusUnauthorized)
+			principal, err := NewPrincipal(claims)
+			if err != nil {
+				response.FromError(w, r, ErrInvalidToken.WithDetails(map[string]interface{}{"reason": err.Error()}))
 				return
 			}
 
-			userID, err := uuid.Parse(sub)
+			revoked, err := v.IsRevoked(r.Context(), principal)
 			if err != nil {
-				http.Error(w, "invalid user id in token", http.StatusUnauthorized)
+				response.FromError(w, r, ErrSessionCheckFailed)
+				return
+			}
+			if revoked {
+				response.FromError(w, r, ErrSessionRevoked)
 				return
 			}
 
-			// Set UserID in context
-			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			// Set Principal and UserID in context
+			ctx := WithPrincipal(r.Context(), principal)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
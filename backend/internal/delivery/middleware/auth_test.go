@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,7 +11,10 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetUserID(t *testing.T) {
@@ -150,7 +155,8 @@ func TestAuthMiddleware(t *testing.T) {
 			tt.setupRequest(req)
 			rec := httptest.NewRecorder()
 
-			middleware := AuthMiddleware(secret)
+			middleware, err := AuthMiddleware(secret)
+			require.NoError(t, err)
 			handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 			}))
@@ -161,3 +167,246 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticateClaims(t *testing.T) {
+	secret := "test-secret"
+	userId := uuid.New()
+	now := time.Now()
+
+	v, err := NewVerifier(VerifierConfig{
+		Algorithms:     []string{"HS256"},
+		Secret:         secret,
+		Issuer:         "https://project.supabase.co/auth/v1",
+		Audience:       []string{"authenticated"},
+		Leeway:         30 * time.Second,
+		RequiredClaims: []string{"exp", "sub", "iat"},
+	})
+	assert.NoError(t, err)
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": userId.String(),
+			"iss": "https://project.supabase.co/auth/v1",
+			"aud": "authenticated",
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name           string
+		mutate         func(claims jwt.MapClaims)
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			mutate:         func(claims jwt.MapClaims) {},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "AudienceList",
+			mutate: func(claims jwt.MapClaims) {
+				claims["aud"] = []string{"other", "authenticated"}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "WrongIssuer",
+			mutate: func(claims jwt.MapClaims) {
+				claims["iss"] = "https://other.supabase.co/auth/v1"
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "MissingIssuer",
+			mutate: func(claims jwt.MapClaims) {
+				delete(claims, "iss")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "WrongAudience",
+			mutate: func(claims jwt.MapClaims) {
+				claims["aud"] = "anon"
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "NotYetValid",
+			mutate: func(claims jwt.MapClaims) {
+				claims["nbf"] = now.Add(time.Minute).Unix()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "NotBeforeWithinLeeway",
+			mutate: func(claims jwt.MapClaims) {
+				claims["nbf"] = now.Add(10 * time.Second).Unix()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "IssuedInFuture",
+			mutate: func(claims jwt.MapClaims) {
+				claims["iat"] = now.Add(time.Minute).Unix()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "ExpiredWithinLeeway",
+			mutate: func(claims jwt.MapClaims) {
+				claims["exp"] = now.Add(-10 * time.Second).Unix()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "ExpiredBeyondLeeway",
+			mutate: func(claims jwt.MapClaims) {
+				claims["exp"] = now.Add(-time.Minute).Unix()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "MissingExp",
+			mutate: func(claims jwt.MapClaims) {
+				delete(claims, "exp")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "MissingRequiredIat",
+			mutate: func(claims jwt.MapClaims) {
+				delete(claims, "iat")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.mutate(claims)
+			tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tokenString)
+			rec := httptest.NewRecorder()
+
+			handler := Authenticate(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+// fakeRevocations holds the known sessions, mapped to whether they are revoked,
+// and denylisted token IDs under a "jti:" prefix. Like session.Service it fails
+// closed: a session it does not know is revoked.
+type fakeRevocations map[string]bool
+
+func (f fakeRevocations) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if sessionID == "unavailable" {
+		return false, errors.New("database unavailable")
+	}
+	revoked, ok := f[sessionID]
+	return revoked || !ok, nil
+}
+
+func (f fakeRevocations) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "unavailable" {
+		return false, errors.New("database unavailable")
+	}
+	return f["jti:"+jti], nil
+}
+
+func TestAuthenticateRevocation(t *testing.T) {
+	secret := "test-secret"
+	userId := uuid.New()
+
+	v, err := NewVerifier(VerifierConfig{
+		Algorithms:  []string{"HS256"},
+		Secret:      secret,
+		Revocations: fakeRevocations{"active": false, "revoked": true, "jti:denied": true},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		sessionID      string
+		local          bool
+		tokenID        string
+		expectedStatus int
+	}{
+		{name: "Active", sessionID: "active", local: true, expectedStatus: http.StatusOK},
+		{name: "Unknown", sessionID: "missing", local: true, expectedStatus: http.StatusUnauthorized},
+		{name: "NoSession", sessionID: "", local: true, expectedStatus: http.StatusUnauthorized},
+		{name: "Revoked", sessionID: "revoked", local: true, expectedStatus: http.StatusUnauthorized},
+		{name: "CheckFailed", sessionID: "unavailable", local: true, expectedStatus: http.StatusServiceUnavailable},
+		{name: "ExternalSession", sessionID: "supabase-session", expectedStatus: http.StatusOK},
+		{name: "ExternalNoSession", sessionID: "", expectedStatus: http.StatusOK},
+		{name: "TokenAllowed", sessionID: "active", local: true, tokenID: "allowed", expectedStatus: http.StatusOK},
+		{name: "TokenDenylisted", sessionID: "active", local: true, tokenID: "denied", expectedStatus: http.StatusUnauthorized},
+		{name: "ExternalTokenDenylisted", sessionID: "supabase-session", tokenID: "denied", expectedStatus: http.StatusUnauthorized},
+		{name: "TokenCheckFailed", sessionID: "active", local: true, tokenID: "unavailable", expectedStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{
+				"sub": userId.String(),
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}
+			if tt.sessionID != "" {
+				claims["session_id"] = tt.sessionID
+			}
+			if tt.local {
+				claims[domain.LocalSessionClaim] = true
+			}
+			if tt.tokenID != "" {
+				claims["jti"] = tt.tokenID
+			}
+			tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tokenString)
+			rec := httptest.NewRecorder()
+
+			handler := Authenticate(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestAuthMiddlewareErrorBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	middleware, err := AuthMiddleware("test-secret")
+	require.NoError(t, err)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body response.ErrorBody
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "missing_authorization", body.Code)
+	assert.Equal(t, "authorization header required", body.Message)
+}
+
+func TestAuthMiddlewareEmptySecret(t *testing.T) {
+	_, err := AuthMiddleware("")
+	assert.Error(t, err)
+}
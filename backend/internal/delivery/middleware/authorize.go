@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+)
+
+// Policy decides whether the authenticated principal may perform the request.
+// A non-nil error denies access and its message is returned to the client.
+type Policy func(r *http.Request, p *Principal) error
+
+// ErrForbidden is a convenience error for policies without a more specific reason
+var ErrForbidden = domain.NewError(domain.KindForbidden, "forbidden", "forbidden")
+
+// RequireRole allows principals holding any of roles
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return authorize(func(r *http.Request, p *Principal) ([]string, error) {
+		for _, role := range roles {
+			if p.HasRole(role) {
+				return nil, nil
+			}
+		}
+		return roles, fmt.Errorf("requires one of roles: %s", strings.Join(roles, ", "))
+	})
+}
+
+// RequireScope allows principals granted all of scopes
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return authorize(func(r *http.Request, p *Principal) ([]string, error) {
+		var missing []string
+		for _, scope := range scopes {
+			if !p.HasScope(scope) {
+				missing = append(missing, scope)
+			}
+		}
+		if len(missing) > 0 {
+			return missing, fmt.Errorf("missing scopes: %s", strings.Join(missing, ", "))
+		}
+		return nil, nil
+	})
+}
+
+// Require allows the request when every policy passes
+func Require(policies ...Policy) func(http.Handler) http.Handler {
+	return authorize(func(r *http.Request, p *Principal) ([]string, error) {
+		for _, policy := range policies {
+			if err := policy(r, p); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+}
+
+func authorize(check func(r *http.Request, p *Principal) ([]string, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				response.FromError(w, r, ErrAuthenticationRequired)
+				return
+			}
+
+			missing, err := check(r, p)
+			if err != nil {
+				writeForbidden(w, r, err, missing)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeForbidden renders a policy denial. Domain errors returned by policies keep
+// their own code and status; any other error becomes a 403 with its message.
+func writeForbidden(w http.ResponseWriter, r *http.Request, err error, missing []string) {
+	var de *domain.Error
+	if errors.As(err, &de) {
+		response.FromError(w, r, de)
+		return
+	}
+	var details map[string]interface{}
+	if len(missing) > 0 {
+		details = map[string]interface{}{"missing": missing}
+	}
+	response.Error(w, r, http.StatusForbidden, "forbidden", err.Error(), details)
+}
+
+// HasRole reports whether the principal has role, either as the "role" claim
+// or listed in app_metadata "roles"
+func (p *Principal) HasRole(role string) bool {
+	if p.Role == role {
+		return true
+	}
+	roles, _ := p.AppMetadata["roles"].([]interface{})
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether scope was granted through the "scope" or "scp" claim
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes(), scope)
+}
+
+// Scopes returns the granted scopes. "scope" is a space-separated string (RFC 8693);
+// "scp" may be a string or an array.
+func (p *Principal) Scopes() []string {
+	var scopes []string
+	for _, name := range []string{"scope", "scp"} {
+		switch v := p.Claims[name].(type) {
+		case string:
+			scopes = append(scopes, strings.Fields(v)...)
+		case []interface{}:
+			for _, s := range v {
+				if s, ok := s.(string); ok {
+					scopes = append(scopes, s)
+				}
+			}
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorize(t *testing.T) {
+	admin := &Principal{
+		UserID: uuid.New(),
+		Role:   "authenticated",
+		AppMetadata: map[string]interface{}{
+			"roles": []interface{}{"admin"},
+		},
+		Claims: jwt.MapClaims{
+			"scope": "reports:read reports:write",
+			"scp":   []interface{}{"users:read"},
+		},
+	}
+	isOwner := func(r *http.Request, p *Principal) error {
+		if r.URL.Query().Get("owner") != p.UserID.String() {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name           string
+		principal      *Principal
+		middleware     func(http.Handler) http.Handler
+		url            string
+		expectedStatus int
+		expectedBody   *response.ErrorBody
+	}{
+		{
+			name:           "RoleClaim",
+			principal:      admin,
+			middleware:     RequireRole("authenticated"),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "RoleFromAppMetadata",
+			principal:      admin,
+			middleware:     RequireRole("editor", "admin"),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "RoleMissing",
+			principal:      admin,
+			middleware:     RequireRole("superuser"),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: &response.ErrorBody{
+				Code:    "forbidden",
+				Message: "requires one of roles: superuser",
+				Details: map[string]interface{}{"missing": []interface{}{"superuser"}},
+			},
+		},
+		{
+			name:           "Scopes",
+			principal:      admin,
+			middleware:     RequireScope("reports:read", "users:read"),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "ScopeMissing",
+			principal:      admin,
+			middleware:     RequireScope("reports:read", "users:write"),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: &response.ErrorBody{
+				Code:    "forbidden",
+				Message: "missing scopes: users:write",
+				Details: map[string]interface{}{"missing": []interface{}{"users:write"}},
+			},
+		},
+		{
+			name:           "PolicyAllows",
+			principal:      admin,
+			middleware:     Require(isOwner),
+			url:            "/?owner=" + admin.UserID.String(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "PolicyDenies",
+			principal:      admin,
+			middleware:     Require(isOwner),
+			url:            "/?owner=someone-else",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   &response.ErrorBody{Code: "forbidden", Message: "forbidden"},
+		},
+		{
+			name:           "Unauthenticated",
+			middleware:     RequireRole("authenticated"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := tt.url
+			if url == "" {
+				url = "/"
+			}
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+
+			handler := tt.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != nil {
+				var body response.ErrorBody
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+				assert.Equal(t, *tt.expectedBody, body)
+			}
+		})
+	}
+}
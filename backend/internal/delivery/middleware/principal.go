@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+)
+
+const PrincipalKey contextKey = "principal"
+
+// Principal is the authenticated caller, built from the verified token claims.
+// Field names follow the claims Supabase emits.
+type Principal struct {
+	UserID       uuid.UUID
+	Email        string
+	Phone        string
+	Role         string
+	SessionID    string
+	LocalSession bool      // SessionID names a session of this service
+	TokenID      string    // the "jti" claim
+	ExpiresAt    time.Time // the "exp" claim, zero when absent
+	AppMetadata  map[string]interface{}
+	UserMetadata map[string]interface{}
+	Claims       jwt.MapClaims
+}
+
+// NewPrincipal builds a Principal from verified claims. "sub" must be a UUID.
+func NewPrincipal(claims jwt.MapClaims) (*Principal, error) {
+	sub, ok := claims["sub"].(string)
+	if !ok {
+		return nil, errors.New("sub claim missing")
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, errors.New("invalid user id in token")
+	}
+
+	p := &Principal{
+		UserID: userID,
+		Claims: claims,
+	}
+	p.Email, _ = claims["email"].(string)
+	p.Phone, _ = claims["phone"].(string)
+	p.Role, _ = claims["role"].(string)
+	p.SessionID, _ = claims["session_id"].(string)
+	p.LocalSession, _ = claims[domain.LocalSessionClaim].(bool)
+	p.TokenID, _ = claims["jti"].(string)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		p.ExpiresAt = exp.Time
+	}
+	p.AppMetadata, _ = claims["app_metadata"].(map[string]interface{})
+	p.UserMetadata, _ = claims["user_metadata"].(map[string]interface{})
+	return p, nil
+}
+
+// Claim returns a raw claim value
+func (p *Principal) Claim(name string) (interface{}, bool) {
+	v, ok := p.Claims[name]
+	return v, ok
+}
+
+// StringClaim returns a claim that is expected to be a string
+func (p *Principal) StringClaim(name string) (string, bool) {
+	v, ok := p.Claims[name].(string)
+	return v, ok
+}
+
+// AppMetadataString returns a string value from app_metadata (e.g. "provider")
+func (p *Principal) AppMetadataString(key string) (string, bool) {
+	v, ok := p.AppMetadata[key].(string)
+	return v, ok
+}
+
+// UserMetadataString returns a string value from user_metadata (e.g. "full_name")
+func (p *Principal) UserMetadataString(key string) (string, bool) {
+	v, ok := p.UserMetadata[key].(string)
+	return v, ok
+}
+
+// WithPrincipal stores p in ctx
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = context.WithValue(ctx, PrincipalKey, p)
+	return context.WithValue(ctx, UserIDKey, p.UserID)
+}
+
+// PrincipalFromContext returns the Principal placed in ctx by the authentication middleware
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(PrincipalKey).(*Principal)
+	return p, ok && p != nil
+}
+
+// GetPrincipal Context method to retrieve the Principal
+func GetPrincipal(ctx context.Context) (*Principal, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, errors.New("principal not found in context")
+	}
+	return p, nil
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPrincipal(t *testing.T) {
+	userId := uuid.New()
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		want    *Principal
+		wantErr bool
+	}{
+		{
+			name: "Supabase",
+			claims: jwt.MapClaims{
+				"sub":           userId.String(),
+				"email":         "hello@example.com",
+				"role":          "authenticated",
+				"session_id":    "5b4c3f1e-session",
+				"jti":           "0f8e-token",
+				"exp":           float64(exp.Unix()),
+				"app_metadata":  map[string]interface{}{"provider": "email"},
+				"user_metadata": map[string]interface{}{"full_name": "Hello"},
+			},
+			want: &Principal{
+				UserID:       userId,
+				Email:        "hello@example.com",
+				Role:         "authenticated",
+				SessionID:    "5b4c3f1e-session",
+				TokenID:      "0f8e-token",
+				ExpiresAt:    exp,
+				AppMetadata:  map[string]interface{}{"provider": "email"},
+				UserMetadata: map[string]interface{}{"full_name": "Hello"},
+			},
+		},
+		{
+			name: "LocalSession",
+			claims: jwt.MapClaims{
+				"sub":                    userId.String(),
+				"session_id":             "5b4c3f1e-session",
+				domain.LocalSessionClaim: true,
+			},
+			want: &Principal{UserID: userId, SessionID: "5b4c3f1e-session", LocalSession: true},
+		},
+		{
+			name:   "SubOnly",
+			claims: jwt.MapClaims{"sub": userId.String()},
+			want:   &Principal{UserID: userId},
+		},
+		{
+			name:    "NoSub",
+			claims:  jwt.MapClaims{"email": "hello@example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "InvalidSub",
+			claims:  jwt.MapClaims{"sub": "not-a-uuid"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPrincipal(tt.claims)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.want.Claims = tt.claims
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	assert.False(t, ok)
+
+	p := &Principal{UserID: uuid.New(), AppMetadata: map[string]interface{}{"provider": "email"}}
+	ctx := WithPrincipal(context.Background(), p)
+
+	got, ok := PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Same(t, p, got)
+
+	userID, err := GetUserID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, p.UserID, userID)
+
+	provider, ok := got.AppMetadataString("provider")
+	assert.True(t, ok)
+	assert.Equal(t, "email", provider)
+}
+
+func TestAuthMiddlewarePrincipal(t *testing.T) {
+	secret := "test-secret"
+	userId := uuid.New()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   userId.String(),
+		"email": "hello@example.com",
+		"role":  "authenticated",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	tokenString, _ := token.SignedString([]byte(secret))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	rec := httptest.NewRecorder()
+
+	var got *Principal
+	middleware, err := AuthMiddleware(secret)
+	require.NoError(t, err)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+	}))
+	handler.ServeHTTP(rec, req)
+
+	require.NotNil(t, got)
+	assert.Equal(t, userId, got.UserID)
+	assert.Equal(t, "hello@example.com", got.Email)
+	assert.Equal(t, "authenticated", got.Role)
+	assert.Equal(t, "hello@example.com", got.Claims["email"])
+}
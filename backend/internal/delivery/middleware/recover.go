@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
+)
+
+// Recoverer recovers from panics in later handlers, logs them with the stack
+// trace and answers with the JSON error envelope instead of an empty 500
+func Recoverer(l *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				// net/http aborts the response on this sentinel; let it through
+				if err, ok := rvr.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rvr)
+				}
+
+				l.ErrorContext(r.Context(), "panic recovered",
+					slog.String("panic", fmt.Sprint(rvr)),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("stack", string(debug.Stack())),
+				)
+				// A hijacked connection can no longer be written to
+				if r.Header.Get("Connection") != "Upgrade" {
+					response.Error(w, r, http.StatusInternalServerError, "internal_error", "internal server error", nil)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
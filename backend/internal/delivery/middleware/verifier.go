@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rh-mithu/rizon/backend/pkg/jwks"
+)
+
+// supportedAlgorithms lists every signing algorithm the Verifier can be configured to accept
+var supportedAlgorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// VerifierConfig selects which algorithms and keys are trusted
+type VerifierConfig struct {
+	// Algorithms is the explicit allow-list of accepted "alg" header values
+	Algorithms []string
+	// Secret verifies HS* tokens
+	Secret string
+	// Keys resolves public keys for RS*, PS*, ES* and EdDSA tokens by "kid"
+	Keys jwks.Provider
+
+	// Issuer, when set, must equal the "iss" claim
+	Issuer string
+	// Audience, when set, must contain at least one of the "aud" values
+	Audience []string
+	// Leeway tolerates clock skew when checking "exp", "nbf" and "iat"
+	Leeway time.Duration
+	// RequiredClaims must be present in every token, e.g. "exp", "sub"
+	RequiredClaims []string
+	// Revocations, when set, rejects tokens whose "jti" is denylisted or, for
+	// tokens minted by this service, whose "session_id" has been revoked
+	Revocations RevocationChecker
+}
+
+// RevocationChecker reports whether a token or its session has been revoked
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, sessionID string) (bool, error)
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// Verifier parses bearer tokens and checks their signature against the configured keys
+type Verifier struct {
+	algorithms     []string
+	secret         []byte
+	keys           jwks.Provider
+	requiredClaims []string
+	revocations    RevocationChecker
+	options        []jwt.ParserOption
+}
+
+func NewVerifier(cfg VerifierConfig) (*Verifier, error) {
+	if len(cfg.Algorithms) == 0 {
+		return nil, errors.New("at least one JWT algorithm must be allowed")
+	}
+	for _, alg := range cfg.Algorithms {
+		if !slices.Contains(supportedAlgorithms, alg) {
+			return nil, fmt.Errorf("unsupported JWT algorithm %q", alg)
+		}
+		if isHMAC(alg) && cfg.Secret == "" {
+			return nil, fmt.Errorf("algorithm %s requires a shared secret", alg)
+		}
+		if !isHMAC(alg) && cfg.Keys == nil {
+			return nil, fmt.Errorf("algorithm %s requires a JWKS source", alg)
+		}
+	}
+	if cfg.Leeway < 0 {
+		return nil, errors.New("JWT leeway must not be negative")
+	}
+
+	// Reject tokens issued in the future; "exp" and "nbf" are always checked when present
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.Algorithms),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithIssuedAt(),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if len(cfg.Audience) > 0 {
+		options = append(options, jwt.WithAudience(cfg.Audience...))
+	}
+	if slices.Contains(cfg.RequiredClaims, "exp") {
+		options = append(options, jwt.WithExpirationRequired())
+	}
+	if slices.Contains(cfg.RequiredClaims, "nbf") {
+		options = append(options, jwt.WithNotBeforeRequired())
+	}
+
+	return &Verifier{
+		algorithms:     slices.Clone(cfg.Algorithms),
+		secret:         []byte(cfg.Secret),
+		keys:           cfg.Keys,
+		requiredClaims: slices.Clone(cfg.RequiredClaims),
+		revocations:    cfg.Revocations,
+		options:        options,
+	}, nil
+}
+
+// Parse validates the token signature, the registered claims and the required claims
+func (v *Verifier) Parse(ctx context.Context, tokenString string) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenString, v.keyFunc(ctx), v.options...)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	for _, name := range v.requiredClaims {
+		if value, ok := claims[name]; !ok || value == nil || value == "" {
+			return nil, fmt.Errorf("%w: %s is required", jwt.ErrTokenRequiredClaimMissing, name)
+		}
+	}
+	return token, nil
+}
+
+// IsRevoked reports whether the principal's token is denylisted or its session
+// has been revoked. Tokens without a jti skip the denylist, and only tokens
+// minted by this service have their session checked.
+func (v *Verifier) IsRevoked(ctx context.Context, p *Principal) (bool, error) {
+	if v.revocations == nil {
+		return false, nil
+	}
+	if p.TokenID != "" {
+		revoked, err := v.revocations.IsTokenRevoked(ctx, p.TokenID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	if !p.LocalSession {
+		return false, nil
+	}
+	return v.revocations.IsRevoked(ctx, p.SessionID)
+}
+
+func (v *Verifier) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		alg := token.Method.Alg()
+		// jwt.WithValidMethods already enforces this; keep the check next to key selection
+		if !slices.Contains(v.algorithms, alg) {
+			return nil, fmt.Errorf("algorithm %s is not allowed", alg)
+		}
+		if isHMAC(alg) {
+			return v.secret, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, err := v.keys.Key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.Algorithm != "" && key.Algorithm != alg {
+			return nil, fmt.Errorf("key %q is for %s, token uses %s", kid, key.Algorithm, alg)
+		}
+		if !keyMatchesAlgorithm(key.Public, alg) {
+			return nil, fmt.Errorf("key %q cannot verify %s", kid, alg)
+		}
+		return key.Public, nil
+	}
+}
+
+func isHMAC(alg string) bool {
+	return strings.HasPrefix(alg, "HS")
+}
+
+func keyMatchesAlgorithm(key interface{}, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/pkg/jwks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toJWK(t *testing.T, kid, alg string, pub crypto.PublicKey) jwks.JWK {
+	t.Helper()
+	enc := base64.RawURLEncoding.EncodeToString
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return jwks.JWK{Kty: "RSA", Kid: kid, Alg: alg, Use: "sig", N: enc(k.N.Bytes()), E: enc(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		b, err := k.Bytes()
+		require.NoError(t, err)
+		size := (len(b) - 1) / 2
+		return jwks.JWK{Kty: "EC", Kid: kid, Alg: alg, Crv: k.Curve.Params().Name, X: enc(b[1 : 1+size]), Y: enc(b[1+size:])}
+	case ed25519.PublicKey:
+		return jwks.JWK{Kty: "OKP", Kid: kid, Alg: alg, Crv: "Ed25519", X: enc(k)}
+	}
+	t.Fatalf("unsupported key type %T", pub)
+	return jwks.JWK{}
+}
+
+func writeJWKS(t *testing.T, keys ...jwks.JWK) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, sub string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"sub": sub,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func TestVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := writeJWKS(t,
+		toJWK(t, "rsa-1", "RS256", &rsaKey.PublicKey),
+		toJWK(t, "ec-1", "ES256", &ecKey.PublicKey),
+		toJWK(t, "ed-1", "", edPub),
+	)
+	keys, err := jwks.NewFileProvider(path)
+	require.NoError(t, err)
+
+	v, err := NewVerifier(VerifierConfig{
+		Algorithms: []string{"HS256", "RS256", "ES256", "EdDSA"},
+		Secret:     "test-secret",
+		Keys:       keys,
+	})
+	require.NoError(t, err)
+
+	sub := uuid.NewString()
+	rsaModulus := rsaKey.PublicKey.N.Bytes()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "HS256", token: signToken(t, jwt.SigningMethodHS256, "", []byte("test-secret"), sub)},
+		{name: "RS256", token: signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, sub)},
+		{name: "ES256", token: signToken(t, jwt.SigningMethodES256, "ec-1", ecKey, sub)},
+		{name: "EdDSA", token: signToken(t, jwt.SigningMethodEdDSA, "ed-1", edKey, sub)},
+		{name: "UnknownKid", token: signToken(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, sub), wantErr: true},
+		{name: "WrongKey", token: signToken(t, jwt.SigningMethodRS256, "rsa-1", otherRSA, sub), wantErr: true},
+		{name: "AlgorithmNotAllowed", token: signToken(t, jwt.SigningMethodRS512, "rsa-1", rsaKey, sub), wantErr: true},
+		{name: "KeyAlgorithmMismatch", token: signToken(t, jwt.SigningMethodES256, "rsa-1", ecKey, sub), wantErr: true},
+		{name: "HMACWithPublicKey", token: signToken(t, jwt.SigningMethodHS256, "rsa-1", rsaModulus, sub), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := v.Parse(context.Background(), tt.token)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, token.Valid)
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     VerifierConfig
+		wantErr bool
+	}{
+		{name: "HS256", cfg: VerifierConfig{Algorithms: []string{"HS256"}, Secret: "s"}},
+		{name: "NoAlgorithms", cfg: VerifierConfig{Secret: "s"}, wantErr: true},
+		{name: "Unsupported", cfg: VerifierConfig{Algorithms: []string{"none"}}, wantErr: true},
+		{name: "HMACWithoutSecret", cfg: VerifierConfig{Algorithms: []string{"HS256"}}, wantErr: true},
+		{name: "RS256WithoutKeys", cfg: VerifierConfig{Algorithms: []string{"RS256"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVerifier(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateJWKSRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	published := []jwks.JWK{toJWK(t, "old", "RS256", &oldKey.PublicKey)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": published})
+	}))
+	defer srv.Close()
+
+	v, err := NewVerifier(VerifierConfig{
+		Algorithms: []string{"RS256"},
+		Keys:       jwks.NewURLProvider(srv.URL, time.Hour, srv.Client()),
+	})
+	require.NoError(t, err)
+
+	handler := Authenticate(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	sub := uuid.NewString()
+	assert.Equal(t, http.StatusOK, call(signToken(t, jwt.SigningMethodRS256, "old", oldKey, sub)))
+
+	// Rotate: the new kid is unknown to the cache, so the provider refetches
+	published = append(published, toJWK(t, "new", "RS256", &newKey.PublicKey))
+	assert.Equal(t, http.StatusOK, call(signToken(t, jwt.SigningMethodRS256, "new", newKey, sub)))
+}
@@ -0,0 +1,123 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+)
+
+// Domain equivalents of the datastore errors
+var (
+	errNotFound       = domain.NewError(domain.KindNotFound, "not_found", "resource not found")
+	errDuplicate      = domain.NewError(domain.KindConflict, "duplicate", "resource already exists")
+	errForeignKey     = domain.NewError(domain.KindConflict, "reference_conflict", "referenced resource does not exist or is still in use")
+	errCheckViolation = domain.NewError(domain.KindInvalid, "constraint_violation", "value violates a constraint")
+	errTimeout        = domain.NewError(domain.KindUnavailable, "timeout", "request timed out, try again")
+	errInvalidCursor  = domain.NewError(domain.KindInvalid, "invalid_cursor", "invalid pagination cursor")
+	errVersion        = domain.NewError(domain.KindConflict, "version_conflict", "resource was modified, reload and try again")
+)
+
+// ErrorBody is the JSON envelope every failed request returns
+type ErrorBody struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// JSON writes v with the given status
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Error writes an error envelope with the given status
+func Error(w http.ResponseWriter, r *http.Request, status int, code, message string, details map[string]interface{}) {
+	JSON(w, status, ErrorBody{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: chiMiddleware.GetReqID(r.Context()),
+	})
+}
+
+// FromError writes err as an error envelope. A *domain.Error keeps its code,
+// message and details, a datastore error is mapped to its domain equivalent,
+// and anything else is reported as an opaque internal error.
+func FromError(w http.ResponseWriter, r *http.Request, err error) {
+	de, ok := asDomainError(err)
+	if !ok {
+		Error(w, r, http.StatusInternalServerError, "internal_error", "internal server error", nil)
+		return
+	}
+	if de.Kind == domain.KindInternal {
+		Error(w, r, http.StatusInternalServerError, de.Code, "internal server error", nil)
+		return
+	}
+	Error(w, r, Status(de.Kind), de.Code, de.Message, de.Details)
+}
+
+// Status maps a domain error kind to an HTTP status code
+func Status(kind domain.Kind) int {
+	switch kind {
+	case domain.KindInvalid:
+		return http.StatusBadRequest
+	case domain.KindUnauthorized:
+		return http.StatusUnauthorized
+	case domain.KindForbidden:
+		return http.StatusForbidden
+	case domain.KindNotFound:
+		return http.StatusNotFound
+	case domain.KindConflict:
+		return http.StatusConflict
+	case domain.KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// IsInternal reports whether err will be rendered as a 5xx and should be logged
+func IsInternal(err error) bool {
+	de, ok := asDomainError(err)
+	if !ok {
+		return true
+	}
+	return Status(de.Kind) >= http.StatusInternalServerError
+}
+
+// asDomainError finds the *domain.Error in err's chain, or derives one from a datastore error
+func asDomainError(err error) (*domain.Error, bool) {
+	var de *domain.Error
+	if errors.As(err, &de) {
+		return de, true
+	}
+
+	var details map[string]interface{}
+	var dse *datastore.Error
+	if errors.As(err, &dse) && dse.Column != "" {
+		details = map[string]interface{}{"field": dse.Column}
+	}
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+		return errNotFound, true
+	case errors.Is(err, datastore.ErrDuplicate):
+		return errDuplicate.WithDetails(details), true
+	case errors.Is(err, datastore.ErrForeignKey):
+		return errForeignKey.WithDetails(details), true
+	case errors.Is(err, datastore.ErrCheckViolation):
+		return errCheckViolation.WithDetails(details), true
+	case errors.Is(err, datastore.ErrTimeout):
+		return errTimeout, true
+	case errors.Is(err, datastore.ErrConflict):
+		return errVersion, true
+	case errors.Is(err, datastore.ErrInvalidCursor):
+		return errInvalidCursor, true
+	}
+	return nil, false
+}
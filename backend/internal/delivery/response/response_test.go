@@ -0,0 +1,103 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromError(t *testing.T) {
+	errNotFound := domain.NewError(domain.KindNotFound, "report_not_found", "report not found")
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedBody   ErrorBody
+	}{
+		{
+			name:           "DomainError",
+			err:            errNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   ErrorBody{Code: "report_not_found", Message: "report not found"},
+		},
+		{
+			name:           "WrappedWithDetails",
+			err:            fmt.Errorf("load: %w", errNotFound.WithDetails(map[string]interface{}{"id": "42"})),
+			expectedStatus: http.StatusNotFound,
+			expectedBody: ErrorBody{
+				Code:    "report_not_found",
+				Message: "report not found",
+				Details: map[string]interface{}{"id": "42"},
+			},
+		},
+		{
+			name:           "Internal",
+			err:            domain.NewError(domain.KindInternal, "db_failure", "connection reset"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   ErrorBody{Code: "db_failure", Message: "internal server error"},
+		},
+		{
+			name:           "DatastoreNotFound",
+			err:            fmt.Errorf("find report: %w", datastore.ErrNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   ErrorBody{Code: "not_found", Message: "resource not found"},
+		},
+		{
+			name:           "DatastoreDuplicate",
+			err:            &datastore.Error{Kind: datastore.ErrDuplicate, Table: "users", Constraint: "users_email_key", Column: "email"},
+			expectedStatus: http.StatusConflict,
+			expectedBody: ErrorBody{
+				Code:    "duplicate",
+				Message: "resource already exists",
+				Details: map[string]interface{}{"field": "email"},
+			},
+		},
+		{
+			name:           "DatastoreTimeout",
+			err:            &datastore.Error{Kind: datastore.ErrTimeout},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   ErrorBody{Code: "timeout", Message: "request timed out, try again"},
+		},
+		{
+			name:           "DatastoreVersionConflict",
+			err:            fmt.Errorf("update report: %w", datastore.ErrConflict),
+			expectedStatus: http.StatusConflict,
+			expectedBody:   ErrorBody{Code: "version_conflict", Message: "resource was modified, reload and try again"},
+		},
+		{
+			name:           "Unknown",
+			err:            errors.New("pq: connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   ErrorBody{Code: "internal_error", Message: "internal server error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := chiMiddleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				FromError(w, r, tt.err)
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var body ErrorBody
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.NotEmpty(t, body.RequestID)
+			body.RequestID = ""
+			assert.Equal(t, tt.expectedBody, body)
+		})
+	}
+}
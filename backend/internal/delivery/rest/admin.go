@@ -0,0 +1,28 @@
+package rest
+
+import (
+	"expvar"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/middleware"
+)
+
+// AdminRole is the role required by the admin endpoints
+const AdminRole = "admin"
+
+// AdminHandler serves operational endpoints to principals holding AdminRole
+type AdminHandler struct{}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
+
+func (h *AdminHandler) Register(handler *Handler) {
+	handler.Protected("/admin", h.Routes, middleware.RequireRole(AdminRole))
+}
+
+// Routes registers the admin endpoints
+func (h *AdminHandler) Routes(r chi.Router) {
+	// expvar counters such as datastore_tx_retries
+	r.Handle("/debug/vars", expvar.Handler())
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminDebugVars(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           string
+		expectedStatus int
+	}{
+		{name: "Admin", role: AdminRole, expectedStatus: http.StatusOK},
+		{name: "User", role: "authenticated", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asRole := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					p := &middleware.Principal{UserID: uuid.New(), Role: tt.role}
+					next.ServeHTTP(w, r.WithContext(middleware.WithPrincipal(r.Context(), p)))
+				})
+			}
+			h := NewHandler(&config.Config{Env: "test"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			h.Register(NewAdminHandler())
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/debug/vars", nil)
+			rec := httptest.NewRecorder()
+			NewRouter(asRole, h).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var vars map[string]json.RawMessage
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&vars))
+				assert.Contains(t, vars, "memstats")
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package rest
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/middleware"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/auth"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/session"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/token"
+)
+
+type AuthHandler struct {
+	l        *slog.Logger
+	service  *auth.Service
+	sessions *session.Service
+	tokens   *token.Service
+}
+
+func NewAuthHandler(l *slog.Logger, service *auth.Service, sessions *session.Service, tokens *token.Service) *AuthHandler {
+	return &AuthHandler{
+		l:        l,
+		service:  service,
+		sessions: sessions,
+		tokens:   tokens,
+	}
+}
+
+func (h *AuthHandler) Register(handler *Handler) {
+	handler.Public("/auth", h.PublicRoutes)
+	handler.Protected("/auth", h.ProtectedRoutes)
+}
+
+// PublicRoutes registers the passwordless login endpoints
+func (h *AuthHandler) PublicRoutes(r chi.Router) {
+	r.Post("/request-link", h.requestLink)
+	r.Post("/verify", h.verify)
+	r.Post("/refresh", h.refresh)
+}
+
+// ProtectedRoutes registers the session management endpoints
+func (h *AuthHandler) ProtectedRoutes(r chi.Router) {
+	r.Post("/logout", h.logout)
+	r.Post("/logout-all", h.logoutAll)
+}
+
+type requestLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type verifyRequest struct {
+	Token string `json:"token"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`
+	UserID           string `json:"user_id"`
+	Email            string `json:"email"`
+	SessionID        string `json:"session_id"`
+}
+
+func newTokenResponse(pair *token.Pair) tokenResponse {
+	return tokenResponse{
+		AccessToken:      pair.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        pair.AccessTokenExpiresAt.Unix(),
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshTokenExpiresAt.Unix(),
+		UserID:           pair.User.ID.String(),
+		Email:            pair.User.Email,
+		SessionID:        pair.Session.ID.String(),
+	}
+}
+
+type logoutAllResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+func (h *AuthHandler) requestLink(w http.ResponseWriter, r *http.Request) {
+	var req requestLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, h.l, "failed to decode request", errInvalidBody)
+		return
+	}
+
+	if err := h.service.RequestLink(r.Context(), req.Email); err != nil {
+		writeError(w, r, h.l, "failed to request login link", err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) verify(w http.ResponseWriter, r *http.Request) {
+	var req verifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, h.l, "failed to decode request", errInvalidBody)
+		return
+	}
+
+	pair, err := h.service.Verify(r.Context(), req.Token, session.Metadata{
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+	})
+	if err != nil {
+		writeError(w, r, h.l, "failed to verify login token", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newTokenResponse(pair))
+}
+
+// clientIP returns the caller's address without the port. RemoteAddr is the
+// peer's host:port, or the forwarded client address when TrustProxyHeaders
+// mounts chiMiddleware.RealIP.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (h *AuthHandler) refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, h.l, "failed to decode request", errInvalidBody)
+		return
+	}
+
+	pair, err := h.tokens.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		writeError(w, r, h.l, "failed to refresh token", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newTokenResponse(pair))
+}
+
+func (h *AuthHandler) logout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, r, h.l, "missing principal", middleware.ErrAuthenticationRequired)
+		return
+	}
+	sessionID, err := uuid.Parse(principal.SessionID)
+	if err != nil {
+		writeError(w, r, h.l, "missing session", errNoSession)
+		return
+	}
+
+	if err = h.sessions.Revoke(r.Context(), principal.UserID, sessionID); err != nil {
+		writeError(w, r, h.l, "failed to revoke session", err)
+		return
+	}
+	if err = h.sessions.RevokeToken(r.Context(), principal.TokenID, principal.ExpiresAt); err != nil {
+		writeError(w, r, h.l, "failed to revoke token", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) logoutAll(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, r, h.l, "missing principal", middleware.ErrAuthenticationRequired)
+		return
+	}
+
+	revoked, err := h.sessions.RevokeAll(r.Context(), principal.UserID)
+	if err != nil {
+		writeError(w, r, h.l, "failed to revoke sessions", err)
+		return
+	}
+	if err = h.sessions.RevokeToken(r.Context(), principal.TokenID, principal.ExpiresAt); err != nil {
+		writeError(w, r, h.l, "failed to revoke token", err)
+		return
+	}
+	response.JSON(w, http.StatusOK, logoutAllResponse{Revoked: revoked})
+}
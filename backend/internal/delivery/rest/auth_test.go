@@ -0,0 +1,29 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		expected   string
+	}{
+		{name: "IPv4", remoteAddr: "203.0.113.7:51234", expected: "203.0.113.7"},
+		{name: "IPv6", remoteAddr: "[2001:db8::1]:51234", expected: "2001:db8::1"},
+		{name: "WithoutPort", remoteAddr: "203.0.113.7", expected: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/auth/verify", nil)
+			req.RemoteAddr = tt.remoteAddr
+			assert.Equal(t, tt.expected, clientIP(req))
+		})
+	}
+}
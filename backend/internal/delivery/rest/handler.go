@@ -1,18 +1,111 @@
 package rest
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
 	"github.com/rh-mithu/rizon/backend/internal/delivery/middleware"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/auth"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/session"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/token"
+	"github.com/rh-mithu/rizon/backend/pkg/jwks"
+	"github.com/rh-mithu/rizon/backend/pkg/mailer"
 	"log/slog"
 )
 
+// Module is a feature that mounts its own sub-routers onto the Handler
+type Module interface {
+	Register(h *Handler)
+}
+
+// Mount is a sub-router registered under a path prefix with its own middleware stack.
+// For protected mounts the stack runs after authentication, so authorization
+// middleware such as middleware.RequireRole("admin") can be attached here.
+// Public and protected mounts may share a prefix.
+type Mount struct {
+	Pattern    string
+	Protected  bool
+	Routes     func(r chi.Router)
+	Middleware []func(http.Handler) http.Handler
+}
+
 type Handler struct {
-	l *slog.Logger
+	l      *slog.Logger
+	cfg    *config.Config
+	mounts []Mount
+}
+
+func NewHandler(c *config.Config, l *slog.Logger) *Handler {
+	return &Handler{
+		l:   l,
+		cfg: c,
+	}
+}
+
+// Public mounts routes that are reachable without authentication
+func (h *Handler) Public(pattern string, routes func(r chi.Router), mw ...func(http.Handler) http.Handler) {
+	h.mounts = append(h.mounts, Mount{Pattern: pattern, Routes: routes, Middleware: mw})
 }
 
-func ProvideHandler(c *config.Config) chi.Router {
+// Protected mounts routes behind the authentication middleware
+func (h *Handler) Protected(pattern string, routes func(r chi.Router), mw ...func(http.Handler) http.Handler) {
+	h.mounts = append(h.mounts, Mount{Pattern: pattern, Protected: true, Routes: routes, Middleware: mw})
+}
+
+// Register lets each module mount its routes
+func (h *Handler) Register(modules ...Module) {
+	for _, m := range modules {
+		m.Register(h)
+	}
+}
+
+func ProvideHandler(c *config.Config, l *slog.Logger, store datastore.DataStore) (chi.Router, error) {
+	sender, err := mailer.New(c, l)
+	if err != nil {
+		return nil, err
+	}
+	sessions := session.NewService(c, store)
+	tokens := token.NewService(c, l, store, sessions)
+
+	h := NewHandler(c, l)
+	h.Register(
+		NewAuthHandler(l, auth.NewService(c, l, store, sender, sessions, tokens), sessions, tokens),
+		NewAdminHandler(),
+	)
+
 	// Middleware
-	authMiddleware := middleware.AuthMiddleware(c.JWTSecret)
-	return NewRouter(authMiddleware)
+	verifier, err := provideVerifier(c, sessions)
+	if err != nil {
+		return nil, err
+	}
+	authMiddleware := middleware.Authenticate(verifier)
+	return NewRouter(authMiddleware, h), nil
+}
+
+func provideVerifier(c *config.Config, revocations middleware.RevocationChecker) (*middleware.Verifier, error) {
+	vc := middleware.VerifierConfig{
+		Algorithms:     c.JWTAlgorithms,
+		Secret:         c.JWTSecret,
+		Issuer:         c.JWTIssuer,
+		Audience:       c.JWTAudience,
+		Leeway:         c.JWTLeeway,
+		RequiredClaims: c.JWTRequiredClaims,
+		Revocations:    revocations,
+	}
+	switch {
+	case c.JWKSFile != "" && c.JWKSURL != "":
+		return nil, errors.New("only one of JWKS_FILE and JWKS_URL may be set")
+	case c.JWKSFile != "":
+		keys, err := jwks.NewFileProvider(c.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		vc.Keys = keys
+	case c.JWKSURL != "":
+		vc.Keys = jwks.NewURLProvider(c.JWKSURL, c.JWKSCacheTTL, nil)
+	}
+	return middleware.NewVerifier(vc)
 }
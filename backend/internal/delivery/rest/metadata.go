@@ -0,0 +1,19 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
+)
+
+type metadataResponse struct {
+	Service     string `json:"service"`
+	Environment string `json:"environment"`
+}
+
+func (h *Handler) metadata(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, metadataResponse{
+		Service:     "rizon",
+		Environment: h.cfg.Env,
+	})
+}
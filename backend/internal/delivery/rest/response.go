@@ -0,0 +1,24 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+)
+
+var (
+	errInvalidBody = domain.NewError(domain.KindInvalid, "invalid_request_body", "invalid request body")
+	errNotFound    = domain.NewError(domain.KindNotFound, "not_found", "resource not found")
+	errNoSession   = domain.NewError(domain.KindInvalid, "no_session", "token is not bound to a session")
+)
+
+// writeError renders err as the JSON error envelope. Errors that surface as a
+// 5xx are logged with msg, since their cause is hidden from the client.
+func writeError(w http.ResponseWriter, r *http.Request, l *slog.Logger, msg string, err error) {
+	if response.IsInternal(err) {
+		l.ErrorContext(r.Context(), msg, slog.String("error", err.Error()))
+	}
+	response.FromError(w, r, err)
+}
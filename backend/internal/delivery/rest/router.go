@@ -5,15 +5,29 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/middleware"
+	"github.com/rh-mithu/rizon/backend/internal/delivery/response"
 )
 
 func NewRouter(
 	authMiddleware func(http.Handler) http.Handler,
+	h *Handler,
 ) chi.Router {
 	r := chi.NewRouter()
 
+	r.Use(chiMiddleware.RequestID)
+	if h.cfg.TrustProxyHeaders {
+		r.Use(chiMiddleware.RealIP)
+	}
 	r.Use(chiMiddleware.Logger)
-	r.Use(authMiddleware)
+	r.Use(middleware.Recoverer(h.l))
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		response.FromError(w, r, errNotFound)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed", nil)
+	})
 
 	// Health Check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -22,5 +36,30 @@ func NewRouter(
 			return
 		}
 	})
+	r.Get("/metadata", h.metadata)
+
+	// Module routes, grouped by prefix so public and protected mounts can share one
+	var patterns []string
+	byPattern := make(map[string][]Mount)
+	for _, m := range h.mounts {
+		if _, ok := byPattern[m.Pattern]; !ok {
+			patterns = append(patterns, m.Pattern)
+		}
+		byPattern[m.Pattern] = append(byPattern[m.Pattern], m)
+	}
+	for _, pattern := range patterns {
+		mounts := byPattern[pattern]
+		r.Route(pattern, func(r chi.Router) {
+			for _, m := range mounts {
+				r.Group(func(r chi.Router) {
+					if m.Protected {
+						r.Use(authMiddleware)
+					}
+					r.Use(m.Middleware...)
+					m.Routes(r)
+				})
+			}
+		})
+	}
 	return r
 }
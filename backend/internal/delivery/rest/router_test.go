@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRouter(t *testing.T) {
+	denyAll := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+	teapot := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+	}
+	ok := func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+
+	h := NewHandler(&config.Config{Env: "test"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.Public("/open", ok)
+	h.Public("/brewing", ok, teapot)
+	h.Protected("/private", ok)
+	h.Public("/shared", func(r chi.Router) {
+		r.Get("/open", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+	h.Protected("/shared", func(r chi.Router) {
+		r.Get("/private", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+	h.Public("/panic", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+	})
+	router := NewRouter(denyAll, h)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectJSON     bool
+	}{
+		{name: "Health", path: "/health", expectedStatus: http.StatusOK},
+		{name: "Metadata", path: "/metadata", expectedStatus: http.StatusOK},
+		{name: "PublicModule", path: "/open", expectedStatus: http.StatusOK},
+		{name: "PublicModuleMiddleware", path: "/brewing", expectedStatus: http.StatusTeapot},
+		{name: "ProtectedModule", path: "/private", expectedStatus: http.StatusUnauthorized},
+		{name: "SharedPrefixPublic", path: "/shared/open", expectedStatus: http.StatusOK},
+		{name: "SharedPrefixProtected", path: "/shared/private", expectedStatus: http.StatusUnauthorized},
+		{name: "NotFound", path: "/missing", expectedStatus: http.StatusNotFound, expectJSON: true},
+		{name: "Panic", path: "/panic", expectedStatus: http.StatusInternalServerError, expectJSON: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectJSON {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestNewRouterTrustProxyHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		trust bool
+		want  string
+	}{
+		{name: "Default", trust: false, want: "198.51.100.4"},
+		{name: "Trusted", trust: true, want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(&config.Config{TrustProxyHeaders: tt.trust}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			h.Public("/ip", func(r chi.Router) {
+				r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte(clientIP(r)))
+				})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "198.51.100.4:51234"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			rec := httptest.NewRecorder()
+			NewRouter(nil, h).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Body.String())
+		})
+	}
+}
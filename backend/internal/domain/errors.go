@@ -0,0 +1,59 @@
+package domain
+
+// Kind classifies an Error; the delivery layer maps each kind to a transport status
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindUnavailable
+)
+
+// Error is a domain error with a stable, machine-readable code
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Details map[string]interface{}
+	Err     error
+}
+
+func NewError(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any Error with the same code, so copies made by WithDetails and
+// Wrap still satisfy errors.Is against the original sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetails returns a copy of e carrying structured details for the client
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Wrap returns a copy of e that records the underlying cause
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
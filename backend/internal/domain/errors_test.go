@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorIs(t *testing.T) {
+	sentinel := NewError(KindConflict, "duplicate", "duplicate")
+	assert.ErrorIs(t, sentinel.WithDetails(map[string]interface{}{"field": "email"}), sentinel)
+	assert.ErrorIs(t, sentinel.Wrap(errors.New("cause")), sentinel)
+	assert.NotErrorIs(t, NewError(KindConflict, "other", "other"), sentinel)
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// LoginToken is a single-use magic-link token. Only the SHA-256 hash of the
+// token is persisted; the plain value is sent to the user by email.
+type LoginToken struct {
+	bun.BaseModel `bun:"table:login_tokens,alias:lt"`
+
+	ID        uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	Email     string     `bun:"email,notnull"`
+	TokenHash string     `bun:"token_hash,notnull,unique"`
+	ExpiresAt time.Time  `bun:"expires_at,notnull"`
+	UsedAt    *time.Time `bun:"used_at"`
+	CreatedAt time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// RefreshToken is an opaque, single-use token that renews an access token.
+// Tokens rotated from the same login share a FamilyID; presenting a token that
+// was already used revokes the whole family. Only the SHA-256 hash is stored.
+// AccessTokenID is the "jti" of the access token issued alongside, so reuse
+// can denylist the access tokens of the family too.
+type RefreshToken struct {
+	bun.BaseModel `bun:"table:refresh_tokens,alias:rt"`
+
+	ID        uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	FamilyID  uuid.UUID  `bun:"family_id,type:uuid,notnull"`
+	ParentID  *uuid.UUID `bun:"parent_id,type:uuid"`
+	UserID    uuid.UUID  `bun:"user_id,type:uuid,notnull"`
+	SessionID uuid.UUID  `bun:"session_id,type:uuid,notnull"`
+	TokenHash string     `bun:"token_hash,notnull,unique"`
+	ExpiresAt time.Time  `bun:"expires_at,notnull"`
+	UsedAt    *time.Time `bun:"used_at"`
+	RevokedAt *time.Time `bun:"revoked_at"`
+	CreatedAt time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+
+	AccessTokenID   string    `bun:"access_token_id,nullzero"`
+	AccessExpiresAt time.Time `bun:"access_expires_at,nullzero"`
+}
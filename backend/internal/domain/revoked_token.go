@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// RevokedToken denylists an access token by its "jti" claim. The entry only
+// has to outlive the token, so it is kept until the token's "exp".
+type RevokedToken struct {
+	bun.BaseModel `bun:"table:revoked_tokens,alias:rvt"`
+
+	JTI       string    `bun:"jti,pk"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+	RevokedAt time.Time `bun:"revoked_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// LocalSessionClaim marks the access tokens this service mints. Only their
+// "session_id" refers to a Session; tokens of other issuers, e.g. Supabase,
+// carry the IDs of sessions kept elsewhere.
+const LocalSessionClaim = "local_session"
+
+// Session is a login of a user on one device. Access tokens carry its ID in the
+// "session_id" claim; revoking the session invalidates every token issued for it.
+type Session struct {
+	bun.BaseModel `bun:"table:sessions,alias:s"`
+
+	ID        uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	UserID    uuid.UUID  `bun:"user_id,type:uuid,notnull"`
+	UserAgent string     `bun:"user_agent"`
+	IPAddress string     `bun:"ip_address"`
+	ExpiresAt time.Time  `bun:"expires_at,notnull"`
+	RevokedAt *time.Time `bun:"revoked_at"`
+	CreatedAt time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/session"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/token"
+	"github.com/rh-mithu/rizon/backend/pkg/mailer"
+)
+
+var (
+	ErrInvalidEmail = domain.NewError(domain.KindInvalid, "invalid_email", "invalid email address")
+	ErrInvalidToken = domain.NewError(domain.KindUnauthorized, "invalid_login_token", "invalid or expired login token")
+)
+
+// Service implements passwordless (magic-link) login
+type Service struct {
+	cfg         *config.Config
+	l           *slog.Logger
+	store       datastore.DataStore
+	sender      mailer.Sender
+	loginTokens *datastore.Repository[domain.LoginToken]
+	sessions    *session.Service
+	tokens      *token.Service
+	now         func() time.Time
+}
+
+func NewService(
+	cfg *config.Config,
+	l *slog.Logger,
+	store datastore.DataStore,
+	sender mailer.Sender,
+	sessions *session.Service,
+	tokens *token.Service,
+) *Service {
+	return &Service{
+		cfg:         cfg,
+		l:           l,
+		store:       store,
+		sender:      sender,
+		loginTokens: datastore.MustRepository[domain.LoginToken](store),
+		sessions:    sessions,
+		tokens:      tokens,
+		now:         time.Now,
+	}
+}
+
+// RequestLink issues a single-use login token for email and sends it as a magic link
+func (s *Service) RequestLink(ctx context.Context, email string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	plain, err := token.NewOpaque()
+	if err != nil {
+		return fmt.Errorf("generate login token: %w", err)
+	}
+
+	loginToken := &domain.LoginToken{
+		Email:     email,
+		TokenHash: token.Hash(plain),
+		ExpiresAt: s.now().Add(s.cfg.MagicLinkTTL),
+	}
+	if err = s.loginTokens.Create(ctx, loginToken); err != nil {
+		return fmt.Errorf("store login token: %w", err)
+	}
+
+	link, err := s.magicLink(plain)
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		From:    s.cfg.MailFrom,
+		To:      email,
+		Subject: "Your Rizon login link",
+		Body: fmt.Sprintf("Tap the link below to sign in. It expires in %s and can only be used once.\n\n%s",
+			s.cfg.MagicLinkTTL, link),
+	}
+	if err = s.sender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send login link: %w", err)
+	}
+	return nil
+}
+
+// Verify consumes a login token, starts a session and exchanges it for an
+// access and refresh token. It all runs in one transaction, so a failure after
+// the token is consumed leaves the magic link usable.
+func (s *Service) Verify(ctx context.Context, loginToken string, meta session.Metadata) (*token.Pair, error) {
+	if loginToken == "" {
+		return nil, ErrInvalidToken
+	}
+
+	var pair *token.Pair
+	err := s.store.RunInTransaction(ctx, nil, func(ctx context.Context, _ datastore.Transaction) error {
+		// Consume the token atomically so it can only ever be exchanged once
+		var consumed []domain.LoginToken
+		err := s.store.RawQuery(ctx,
+			`UPDATE login_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL AND expires_at > ? RETURNING *`,
+			[]interface{}{s.now(), token.Hash(loginToken), s.now()},
+			&consumed,
+		)
+		if err != nil {
+			return fmt.Errorf("consume login token: %w", err)
+		}
+		if len(consumed) == 0 {
+			return ErrInvalidToken
+		}
+
+		var users []domain.User
+		err = s.store.RawQuery(ctx,
+			`INSERT INTO users (email) VALUES (?) ON CONFLICT (email) DO UPDATE SET updated_at = ? RETURNING *`,
+			[]interface{}{consumed[0].Email, s.now()},
+			&users,
+		)
+		if err != nil {
+			return fmt.Errorf("upsert user: %w", err)
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("upsert user: no row returned")
+		}
+		user := &users[0]
+
+		sess, err := s.sessions.Create(ctx, user.ID, meta)
+		if err != nil {
+			return err
+		}
+
+		pair, err = s.tokens.Issue(ctx, user, sess)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+func (s *Service) magicLink(token string) (string, error) {
+	u, err := url.Parse(s.cfg.MagicLinkURL)
+	if err != nil {
+		return "", fmt.Errorf("parse magic link url: %w", err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
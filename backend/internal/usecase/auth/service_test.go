@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/session"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/token"
+	"github.com/rh-mithu/rizon/backend/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	datastore.DataStore
+	inserted []interface{}
+
+	queries    []string // raw queries, prefixed with "tx:" when run in a transaction
+	rawErr     map[string]error
+	rolledBack bool
+}
+
+type txKey struct{}
+
+func (f *fakeStore) RunInTransaction(ctx context.Context, opts *datastore.TxOptions, fn func(ctx context.Context, tx datastore.Transaction) error) error {
+	err := fn(context.WithValue(ctx, txKey{}, true), nil)
+	f.rolledBack = err != nil
+	return err
+}
+
+// RawQuery consumes any login token and fails the statements listed in rawErr,
+// keyed by their first word and table, e.g. "INSERT users"
+func (f *fakeStore) RawQuery(ctx context.Context, query string, args []interface{}, dest interface{}) error {
+	fields := strings.Fields(query)
+	key := fields[0] + " " + fields[1]
+	if fields[0] == "INSERT" {
+		key = fields[0] + " " + fields[2]
+	}
+	if ctx.Value(txKey{}) != nil {
+		key = "tx:" + key
+	}
+	f.queries = append(f.queries, key)
+	if err := f.rawErr[strings.TrimPrefix(key, "tx:")]; err != nil {
+		return err
+	}
+	if consumed, ok := dest.(*[]domain.LoginToken); ok {
+		*consumed = []domain.LoginToken{{Email: "hello@example.com"}}
+	}
+	return nil
+}
+
+func (f *fakeStore) Insert(ctx context.Context, table string, data interface{}) (*datastore.WriteResult, error) {
+	f.inserted = append(f.inserted, data)
+	return &datastore.WriteResult{RowsAffected: 1}, nil
+}
+
+type fakeSender struct {
+	sent []mailer.Message
+}
+
+func (f *fakeSender) Send(ctx context.Context, msg mailer.Message) error {
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func newTestService() (*Service, *fakeStore, *fakeSender) {
+	cfg := &config.Config{
+		JWTSecret:      "test-secret",
+		MagicLinkURL:   "rizon://auth/verify",
+		MagicLinkTTL:   15 * time.Minute,
+		AccessTokenTTL: time.Hour,
+	}
+	store := &fakeStore{}
+	sender := &fakeSender{}
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sessions := session.NewService(cfg, store)
+	tokens := token.NewService(cfg, l, store, sessions)
+	return NewService(cfg, l, store, sender, sessions, tokens), store, sender
+}
+
+func TestRequestLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		wantTo  string
+		wantErr error
+	}{
+		{
+			name:   "Normalized",
+			email:  "  Hello@Example.com ",
+			wantTo: "hello@example.com",
+		},
+		{
+			name:    "Invalid",
+			email:   "not-an-email",
+			wantErr: ErrInvalidEmail,
+		},
+		{
+			name:    "DisplayName",
+			email:   "Hello <hello@example.com>",
+			wantErr: ErrInvalidEmail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, sender := newTestService()
+
+			err := s.RequestLink(context.Background(), tt.email)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, store.inserted)
+				assert.Empty(t, sender.sent)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, store.inserted, 1)
+			require.Len(t, sender.sent, 1)
+
+			msg := sender.sent[0]
+			assert.Equal(t, tt.wantTo, msg.To)
+
+			idx := strings.Index(msg.Body, "rizon://")
+			require.GreaterOrEqual(t, idx, 0)
+			link, err := url.Parse(strings.TrimSpace(msg.Body[idx:]))
+			require.NoError(t, err)
+			plain := link.Query().Get("token")
+			require.NotEmpty(t, plain)
+
+			stored := store.inserted[0].(*domain.LoginToken)
+			assert.Equal(t, tt.wantTo, stored.Email)
+			assert.Equal(t, token.Hash(plain), stored.TokenHash)
+			assert.NotContains(t, stored.TokenHash, plain)
+		})
+	}
+}
+
+func TestVerifyEmptyToken(t *testing.T) {
+	s, _, _ := newTestService()
+	_, err := s.Verify(context.Background(), "", session.Metadata{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifyRollsBackOnFailure(t *testing.T) {
+	s, store, _ := newTestService()
+	errUpsert := errors.New("connection reset")
+	store.rawErr = map[string]error{"INSERT users": errUpsert}
+
+	_, err := s.Verify(context.Background(), "plain-token", session.Metadata{})
+	assert.ErrorIs(t, err, errUpsert)
+	assert.Equal(t, []string{"tx:UPDATE login_tokens", "tx:INSERT users"}, store.queries)
+	assert.True(t, store.rolledBack, "consuming the login token is rolled back")
+}
@@ -0,0 +1,164 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+	"github.com/rh-mithu/rizon/backend/pkg/cache"
+)
+
+// Service creates and revokes sessions, and keeps the denylist of revoked
+// access tokens. Revocation lookups go through an in-memory LRU in front of
+// Postgres, so a revocation made on another replica takes effect there within
+// cfg.SessionCacheTTL.
+type Service struct {
+	cfg           *config.Config
+	store         datastore.DataStore
+	sessions      *datastore.Repository[domain.Session]
+	revokedTokens *datastore.Repository[domain.RevokedToken]
+	revoked       *cache.LRU[uuid.UUID, bool]
+	revokedJTIs   *cache.LRU[string, bool]
+	now           func() time.Time
+}
+
+func NewService(cfg *config.Config, store datastore.DataStore) *Service {
+	return &Service{
+		cfg:           cfg,
+		store:         store,
+		sessions:      datastore.MustRepository[domain.Session](store),
+		revokedTokens: datastore.MustRepository[domain.RevokedToken](store),
+		revoked:       cache.NewLRU[uuid.UUID, bool](cfg.SessionCacheSize, cfg.SessionCacheTTL),
+		revokedJTIs:   cache.NewLRU[string, bool](cfg.SessionCacheSize, cfg.SessionCacheTTL),
+		now:           time.Now,
+	}
+}
+
+// Metadata describes the client a session was created from
+type Metadata struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Create starts a new session for userID
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, meta Metadata) (*domain.Session, error) {
+	session := &domain.Session{
+		ID:        uuid.New(),
+		UserID:    userID,
+		UserAgent: meta.UserAgent,
+		IPAddress: meta.IPAddress,
+		ExpiresAt: s.now().Add(s.cfg.SessionTTL),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+	return session, nil
+}
+
+// IsRevoked reports whether the session has been revoked or has expired. It
+// fails closed: a malformed session ID, or one with no session row, is revoked.
+func (s *Service) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return true, nil
+	}
+	if revoked, ok := s.revoked.Get(id); ok {
+		return revoked, nil
+	}
+
+	session, err := s.sessions.Get(ctx, id)
+	if errors.Is(err, datastore.ErrNotFound) {
+		s.revoked.Set(id, true)
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("find session: %w", err)
+	}
+
+	revoked := session.RevokedAt != nil || s.now().After(session.ExpiresAt)
+	s.revoked.Set(id, revoked)
+	return revoked, nil
+}
+
+// Revoke invalidates a single session owned by userID
+func (s *Service) Revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	var ids []uuid.UUID
+	err := s.store.RawQuery(ctx,
+		`UPDATE sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL RETURNING id`,
+		[]interface{}{s.now(), sessionID, userID},
+		&ids,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	s.revoked.Set(sessionID, true)
+	return nil
+}
+
+// RevokeAll invalidates every active session of userID and returns how many were revoked
+func (s *Service) RevokeAll(ctx context.Context, userID uuid.UUID) (int, error) {
+	var ids []uuid.UUID
+	err := s.store.RawQuery(ctx,
+		`UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL RETURNING id`,
+		[]interface{}{s.now(), userID},
+		&ids,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", err)
+	}
+	for _, id := range ids {
+		s.revoked.Set(id, true)
+	}
+	return len(ids), nil
+}
+
+// RevokeToken denylists the access token with ID jti until expiresAt, after
+// which it is rejected as expired anyway. A token without "exp" is denylisted
+// for cfg.SessionTTL, the longest any session lives.
+func (s *Service) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	if expiresAt.IsZero() {
+		expiresAt = s.now().Add(s.cfg.SessionTTL)
+	}
+
+	_, err := s.revokedTokens.Upsert(ctx,
+		&domain.RevokedToken{JTI: jti, ExpiresAt: expiresAt},
+		&datastore.UpsertOption{Conflict: []string{"jti"}, DoNothing: true},
+	)
+	if err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+	s.revokedJTIs.Set(jti, true)
+
+	// Entries of tokens that have expired since no longer matter
+	_, err = s.store.DeleteMany(ctx, s.revokedTokens.Meta().Table, map[string]interface{}{"expires_at__lt": s.now()})
+	if err != nil {
+		return fmt.Errorf("purge revoked tokens: %w", err)
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token with ID jti is denylisted.
+// Tokens without a jti are never denylisted.
+func (s *Service) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	if revoked, ok := s.revokedJTIs.Get(jti); ok {
+		return revoked, nil
+	}
+
+	n, err := s.revokedTokens.Count(ctx, &datastore.QueryOption{Filter: map[string]interface{}{"jti": jti}})
+	if err != nil {
+		return false, fmt.Errorf("find revoked token: %w", err)
+	}
+	s.revokedJTIs.Set(jti, n > 0)
+	return n > 0, nil
+}
@@ -0,0 +1,132 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore serves sessions by ID and denylisted token IDs, and counts lookups
+type fakeStore struct {
+	datastore.DataStore
+	sessions map[uuid.UUID]*domain.Session
+	denied   map[string]time.Time
+	lookups  int
+}
+
+func (f *fakeStore) Upsert(ctx context.Context, table string, data interface{}, opts *datastore.UpsertOption) (*datastore.WriteResult, error) {
+	rt := data.(*domain.RevokedToken)
+	f.denied[rt.JTI] = rt.ExpiresAt
+	return &datastore.WriteResult{RowsAffected: 1}, nil
+}
+
+func (f *fakeStore) DeleteMany(ctx context.Context, table string, filter map[string]interface{}) (*datastore.WriteResult, error) {
+	var n int64
+	for jti, expiresAt := range f.denied {
+		if expiresAt.Before(filter["expires_at__lt"].(time.Time)) {
+			delete(f.denied, jti)
+			n++
+		}
+	}
+	return &datastore.WriteResult{RowsAffected: n}, nil
+}
+
+func (f *fakeStore) Count(ctx context.Context, table, alias string, model interface{}, opts *datastore.QueryOption) (int, error) {
+	f.lookups++
+	if _, ok := f.denied[opts.Filter["jti"].(string)]; ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (f *fakeStore) FindOne(ctx context.Context, table, alias string, dest interface{}, opts *datastore.QueryOption) error {
+	f.lookups++
+	sess, ok := f.sessions[opts.Filter["id"].(uuid.UUID)]
+	if !ok {
+		return datastore.ErrNotFound
+	}
+	*dest.(*domain.Session) = *sess
+	return nil
+}
+
+func TestIsRevoked(t *testing.T) {
+	now := time.Now()
+	live := &domain.Session{ID: uuid.New(), ExpiresAt: now.Add(time.Hour)}
+	revoked := &domain.Session{ID: uuid.New(), ExpiresAt: now.Add(time.Hour), RevokedAt: &now}
+	expired := &domain.Session{ID: uuid.New(), ExpiresAt: now.Add(-time.Minute)}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		expected  bool
+	}{
+		{name: "Live", sessionID: live.ID.String(), expected: false},
+		{name: "Revoked", sessionID: revoked.ID.String(), expected: true},
+		{name: "Expired", sessionID: expired.ID.String(), expected: true},
+		{name: "Missing", sessionID: uuid.NewString(), expected: true},
+		{name: "Malformed", sessionID: "not-a-uuid", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{sessions: map[uuid.UUID]*domain.Session{
+				live.ID: live, revoked.ID: revoked, expired.ID: expired,
+			}}
+			s := NewService(&config.Config{SessionCacheSize: 10, SessionCacheTTL: time.Minute}, store)
+
+			for range 2 {
+				got, err := s.IsRevoked(context.Background(), tt.sessionID)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, got)
+			}
+			assert.LessOrEqual(t, store.lookups, 1, "the verdict is cached")
+		})
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cfg := &config.Config{SessionTTL: time.Hour, SessionCacheSize: 10, SessionCacheTTL: time.Minute}
+	store := &fakeStore{denied: map[string]time.Time{"stale": now.Add(-time.Minute)}}
+	s := NewService(cfg, store)
+
+	require.NoError(t, s.RevokeToken(ctx, "with-exp", now.Add(15*time.Minute)))
+	require.NoError(t, s.RevokeToken(ctx, "without-exp", time.Time{}))
+	require.NoError(t, s.RevokeToken(ctx, "", now.Add(time.Minute)))
+
+	assert.Equal(t, now.Add(15*time.Minute), store.denied["with-exp"])
+	assert.WithinDuration(t, now.Add(cfg.SessionTTL), store.denied["without-exp"], time.Second)
+	assert.NotContains(t, store.denied, "stale", "expired entries are purged")
+	assert.Len(t, store.denied, 2)
+
+	tests := []struct {
+		name     string
+		jti      string
+		expected bool
+		lookups  int
+	}{
+		{name: "Revoked", jti: "with-exp", expected: true, lookups: 0},
+		{name: "Unknown", jti: "other", expected: false, lookups: 1},
+		{name: "Empty", jti: "", expected: false, lookups: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.lookups = 0
+			for range 2 {
+				got, err := s.IsTokenRevoked(ctx, tt.jti)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, got)
+			}
+			assert.Equal(t, tt.lookups, store.lookups, "the verdict is cached")
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/session"
+)
+
+var (
+	ErrInvalidRefreshToken = domain.NewError(domain.KindUnauthorized, "invalid_refresh_token", "invalid or expired refresh token")
+	ErrRefreshTokenReused  = domain.NewError(domain.KindUnauthorized, "refresh_token_reused", "refresh token reuse detected")
+)
+
+// Service mints access tokens and rotates refresh tokens
+type Service struct {
+	cfg           *config.Config
+	l             *slog.Logger
+	store         datastore.DataStore
+	users         *datastore.Repository[domain.User]
+	sessionRepo   *datastore.Repository[domain.Session]
+	refreshTokens *datastore.Repository[domain.RefreshToken]
+	sessions      *session.Service
+	now           func() time.Time
+}
+
+func NewService(cfg *config.Config, l *slog.Logger, store datastore.DataStore, sessions *session.Service) *Service {
+	return &Service{
+		cfg:           cfg,
+		l:             l,
+		store:         store,
+		users:         datastore.MustRepository[domain.User](store),
+		sessionRepo:   datastore.MustRepository[domain.Session](store),
+		refreshTokens: datastore.MustRepository[domain.RefreshToken](store),
+		sessions:      sessions,
+		now:           time.Now,
+	}
+}
+
+// Pair is an access token together with the refresh token that renews it
+type Pair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+	User                  *domain.User
+	Session               *domain.Session
+}
+
+// Issue starts a new refresh token family for a freshly created session
+func (s *Service) Issue(ctx context.Context, user *domain.User, sess *domain.Session) (*Pair, error) {
+	return s.issue(ctx, user, sess, sess.ID, nil)
+}
+
+// Refresh exchanges a refresh token for a new pair. The presented token is
+// consumed; presenting it again revokes its family and session.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Pair, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	hash := Hash(refreshToken)
+	now := s.now()
+
+	// Consuming the token and issuing its successor commit together, so a
+	// failed refresh can be retried instead of passing for token reuse
+	var pair *Pair
+	err := s.store.RunInTransaction(ctx, nil, func(ctx context.Context, _ datastore.Transaction) error {
+		// Consume the token atomically; only tokens of live sessions qualify
+		var consumed []domain.RefreshToken
+		err := s.store.RawQuery(ctx,
+			`UPDATE refresh_tokens SET used_at = ?
+			WHERE token_hash = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?
+			AND session_id IN (SELECT id FROM sessions WHERE revoked_at IS NULL AND expires_at > ?)
+			RETURNING *`,
+			[]interface{}{now, hash, now, now},
+			&consumed,
+		)
+		if err != nil {
+			return fmt.Errorf("consume refresh token: %w", err)
+		}
+		if len(consumed) == 0 {
+			return nil
+		}
+		current := consumed[0]
+
+		user, err := s.users.Get(ctx, current.UserID)
+		if err != nil {
+			return fmt.Errorf("find user: %w", err)
+		}
+		sess, err := s.sessionRepo.Get(ctx, current.SessionID)
+		if err != nil {
+			return fmt.Errorf("find session: %w", err)
+		}
+
+		pair, err = s.issue(ctx, user, sess, current.FamilyID, &current.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Nothing was consumed; revoking a reused family must not roll back with it
+	if pair == nil {
+		return nil, s.rejectRefresh(ctx, hash)
+	}
+	return pair, nil
+}
+
+// rejectRefresh explains why a refresh token could not be consumed and, when
+// it had already been used, revokes every token in its family
+func (s *Service) rejectRefresh(ctx context.Context, hash string) error {
+	presented, err := s.refreshTokens.FindOne(ctx, &datastore.QueryOption{
+		Filter: map[string]interface{}{"token_hash": hash},
+	})
+	if errors.Is(err, datastore.ErrNotFound) {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return fmt.Errorf("find refresh token: %w", err)
+	}
+	if presented.UsedAt == nil {
+		return ErrInvalidRefreshToken
+	}
+
+	s.l.WarnContext(ctx, "refresh token reuse detected, revoking family",
+		slog.String("family_id", presented.FamilyID.String()),
+		slog.String("user_id", presented.UserID.String()),
+	)
+	if err = s.RevokeFamily(ctx, presented.FamilyID); err != nil {
+		return err
+	}
+	if err = s.sessions.Revoke(ctx, presented.UserID, presented.SessionID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// RevokeFamily revokes every outstanding refresh token rotated from the same
+// login and denylists the access tokens issued with them that are still live
+func (s *Service) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	now := s.now()
+	var revoked []domain.RefreshToken
+	err := s.store.RawQuery(ctx,
+		`UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL RETURNING *`,
+		[]interface{}{now, familyID},
+		&revoked,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke refresh token family: %w", err)
+	}
+
+	for _, rt := range revoked {
+		if rt.AccessTokenID == "" || !rt.AccessExpiresAt.After(now) {
+			continue
+		}
+		if err = s.sessions.RevokeToken(ctx, rt.AccessTokenID, rt.AccessExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) issue(
+	ctx context.Context,
+	user *domain.User,
+	sess *domain.Session,
+	familyID uuid.UUID,
+	parentID *uuid.UUID,
+) (*Pair, error) {
+	accessTokenID := uuid.NewString()
+	accessToken, accessExpiresAt, err := s.signAccessToken(user, sess, accessTokenID)
+	if err != nil {
+		return nil, fmt.Errorf("sign access token: %w", err)
+	}
+
+	refreshToken, err := NewOpaque()
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+	// A refresh token never outlives its session
+	refreshExpiresAt := s.now().Add(s.cfg.RefreshTokenTTL)
+	if sess.ExpiresAt.Before(refreshExpiresAt) {
+		refreshExpiresAt = sess.ExpiresAt
+	}
+
+	record := &domain.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		ParentID:  parentID,
+		UserID:    user.ID,
+		SessionID: sess.ID,
+		TokenHash: Hash(refreshToken),
+		ExpiresAt: refreshExpiresAt,
+
+		AccessTokenID:   accessTokenID,
+		AccessExpiresAt: accessExpiresAt,
+	}
+	if err = s.refreshTokens.Create(ctx, record); err != nil {
+		return nil, fmt.Errorf("store refresh token: %w", err)
+	}
+
+	return &Pair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshExpiresAt,
+		User:                  user,
+		Session:               sess,
+	}, nil
+}
+
+// signAccessToken mints an HS256 JWT with ID jti accepted by middleware.AuthMiddleware
+func (s *Service) signAccessToken(user *domain.User, sess *domain.Session, jti string) (string, time.Time, error) {
+	now := s.now()
+	expiresAt := now.Add(s.cfg.AccessTokenTTL)
+
+	claims := jwt.MapClaims{
+		"jti":        jti,
+		"sub":        user.ID.String(),
+		"email":      user.Email,
+		"session_id": sess.ID.String(),
+		"iat":        now.Unix(),
+		"nbf":        now.Unix(),
+		"exp":        expiresAt.Unix(),
+	}
+	// The session ID refers to this service's sessions table
+	claims[domain.LocalSessionClaim] = true
+	// Mint tokens that pass the issuer and audience checks configured for the middleware
+	if s.cfg.JWTIssuer != "" {
+		claims["iss"] = s.cfg.JWTIssuer
+	}
+	if len(s.cfg.JWTAudience) > 0 {
+		claims["aud"] = s.cfg.JWTAudience
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(s.cfg.JWTSecret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
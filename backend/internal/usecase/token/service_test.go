@@ -0,0 +1,244 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/rh-mithu/rizon/backend/driver/datastore"
+	"github.com/rh-mithu/rizon/backend/internal/domain"
+	"github.com/rh-mithu/rizon/backend/internal/usecase/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore emulates the handful of queries the token service issues
+type fakeStore struct {
+	datastore.DataStore
+	users    map[uuid.UUID]*domain.User
+	sessions map[uuid.UUID]*domain.Session
+	tokens   []*domain.RefreshToken
+	denied   map[string]time.Time
+
+	insertErr error // fails inserting refresh tokens
+}
+
+// RunInTransaction restores the refresh tokens when fn fails
+func (f *fakeStore) RunInTransaction(ctx context.Context, opts *datastore.TxOptions, fn func(ctx context.Context, tx datastore.Transaction) error) error {
+	saved := make([]domain.RefreshToken, len(f.tokens))
+	for i, t := range f.tokens {
+		saved[i] = *t
+	}
+	err := fn(ctx, nil)
+	if err != nil {
+		f.tokens = f.tokens[:len(saved)]
+		for i := range saved {
+			*f.tokens[i] = saved[i]
+		}
+	}
+	return err
+}
+
+func (f *fakeStore) Upsert(ctx context.Context, table string, data interface{}, opts *datastore.UpsertOption) (*datastore.WriteResult, error) {
+	rt := data.(*domain.RevokedToken)
+	f.denied[rt.JTI] = rt.ExpiresAt
+	return &datastore.WriteResult{RowsAffected: 1}, nil
+}
+
+func (f *fakeStore) DeleteMany(ctx context.Context, table string, filter map[string]interface{}) (*datastore.WriteResult, error) {
+	return &datastore.WriteResult{}, nil
+}
+
+func (f *fakeStore) Insert(ctx context.Context, table string, data interface{}) (*datastore.WriteResult, error) {
+	switch v := data.(type) {
+	case *domain.RefreshToken:
+		if f.insertErr != nil {
+			return nil, f.insertErr
+		}
+		f.tokens = append(f.tokens, v)
+	case *domain.Session:
+		f.sessions[v.ID] = v
+	}
+	return &datastore.WriteResult{RowsAffected: 1}, nil
+}
+
+func (f *fakeStore) FindOne(ctx context.Context, table, alias string, dest interface{}, opts *datastore.QueryOption) error {
+	switch v := dest.(type) {
+	case *domain.User:
+		u, ok := f.users[opts.Filter["id"].(uuid.UUID)]
+		if !ok {
+			return datastore.ErrNotFound
+		}
+		*v = *u
+	case *domain.Session:
+		s, ok := f.sessions[opts.Filter["id"].(uuid.UUID)]
+		if !ok {
+			return datastore.ErrNotFound
+		}
+		*v = *s
+	case *domain.RefreshToken:
+		for _, t := range f.tokens {
+			if t.TokenHash == opts.Filter["token_hash"] {
+				*v = *t
+				return nil
+			}
+		}
+		return datastore.ErrNotFound
+	}
+	return nil
+}
+
+func (f *fakeStore) RawQuery(ctx context.Context, query string, args []interface{}, dest interface{}) error {
+	now := args[0].(time.Time)
+	switch {
+	case strings.HasPrefix(query, "UPDATE refresh_tokens SET used_at"):
+		out := dest.(*[]domain.RefreshToken)
+		for _, t := range f.tokens {
+			sess := f.sessions[t.SessionID]
+			if t.TokenHash == args[1] && t.UsedAt == nil && t.RevokedAt == nil && sess.RevokedAt == nil {
+				t.UsedAt = &now
+				*out = append(*out, *t)
+			}
+		}
+	case strings.HasPrefix(query, "UPDATE refresh_tokens SET revoked_at"):
+		out := dest.(*[]domain.RefreshToken)
+		for _, t := range f.tokens {
+			if t.FamilyID == args[1] && t.RevokedAt == nil {
+				t.RevokedAt = &now
+				*out = append(*out, *t)
+			}
+		}
+	case strings.HasPrefix(query, "UPDATE sessions SET revoked_at"):
+		if s, ok := f.sessions[args[1].(uuid.UUID)]; ok && s.RevokedAt == nil {
+			s.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func newTestService(t *testing.T) (*Service, *fakeStore, *domain.User, *domain.Session) {
+	t.Helper()
+	cfg := &config.Config{
+		JWTSecret:        "test-secret",
+		JWTIssuer:        "rizon",
+		AccessTokenTTL:   15 * time.Minute,
+		RefreshTokenTTL:  24 * time.Hour,
+		SessionTTL:       time.Hour,
+		SessionCacheSize: 10,
+	}
+	store := &fakeStore{
+		users:    map[uuid.UUID]*domain.User{},
+		sessions: map[uuid.UUID]*domain.Session{},
+		denied:   map[string]time.Time{},
+	}
+	user := &domain.User{ID: uuid.New(), Email: "hello@example.com"}
+	store.users[user.ID] = user
+
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sessions := session.NewService(cfg, store)
+	sess, err := sessions.Create(context.Background(), user.ID, session.Metadata{})
+	require.NoError(t, err)
+
+	return NewService(cfg, l, store, sessions), store, user, sess
+}
+
+func TestIssue(t *testing.T) {
+	s, store, user, sess := newTestService(t)
+
+	pair, err := s.Issue(context.Background(), user, sess)
+	require.NoError(t, err)
+
+	require.Len(t, store.tokens, 1)
+	stored := store.tokens[0]
+	assert.Equal(t, Hash(pair.RefreshToken), stored.TokenHash)
+	assert.Equal(t, sess.ID, stored.FamilyID)
+	assert.Nil(t, stored.ParentID)
+	assert.Equal(t, sess.ExpiresAt, pair.RefreshTokenExpiresAt, "refresh token is capped at session expiry")
+
+	token, err := jwt.Parse(pair.AccessToken, func(token *jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer("rizon"))
+	require.NoError(t, err)
+
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, user.ID.String(), claims["sub"])
+	assert.Equal(t, user.Email, claims["email"])
+	assert.Equal(t, sess.ID.String(), claims["session_id"])
+	assert.Equal(t, true, claims[domain.LocalSessionClaim])
+	assert.Equal(t, stored.AccessTokenID, claims["jti"])
+	assert.Equal(t, pair.AccessTokenExpiresAt, stored.AccessExpiresAt)
+}
+
+func TestRefreshRotation(t *testing.T) {
+	ctx := context.Background()
+	s, store, user, sess := newTestService(t)
+
+	first, err := s.Issue(ctx, user, sess)
+	require.NoError(t, err)
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	require.Len(t, store.tokens, 2)
+	assert.Equal(t, store.tokens[0].FamilyID, store.tokens[1].FamilyID)
+	assert.Equal(t, &store.tokens[0].ID, store.tokens[1].ParentID)
+
+	third, err := s.Refresh(ctx, second.RefreshToken)
+	require.NoError(t, err)
+
+	// Replaying an already rotated token revokes the family and the session
+	_, err = s.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	for _, rt := range store.tokens {
+		assert.NotNil(t, rt.RevokedAt)
+	}
+	assert.NotNil(t, store.sessions[sess.ID].RevokedAt)
+
+	// ...and denylists every access token of the family until it expires
+	require.Len(t, store.denied, 3)
+	for _, rt := range store.tokens {
+		assert.Equal(t, rt.AccessExpiresAt, store.denied[rt.AccessTokenID])
+	}
+
+	_, err = s.Refresh(ctx, third.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRefreshRetriesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	s, store, user, sess := newTestService(t)
+
+	first, err := s.Issue(ctx, user, sess)
+	require.NoError(t, err)
+
+	errInsert := errors.New("connection reset")
+	store.insertErr = errInsert
+	_, err = s.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, errInsert)
+	assert.Nil(t, store.tokens[0].UsedAt, "consuming the refresh token is rolled back")
+
+	// The retry is not mistaken for reuse
+	store.insertErr = nil
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, second.RefreshToken)
+	assert.Nil(t, store.sessions[sess.ID].RevokedAt)
+	assert.Empty(t, store.denied)
+}
+
+func TestRefreshUnknownToken(t *testing.T) {
+	s, _, _, _ := newTestService(t)
+
+	_, err := s.Refresh(context.Background(), "")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, err = s.Refresh(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS users
+(
+    id         UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    email      TEXT        NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS login_tokens
+(
+    id         UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    email      TEXT        NOT NULL,
+    token_hash TEXT        NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS login_tokens_email_idx ON login_tokens (email);
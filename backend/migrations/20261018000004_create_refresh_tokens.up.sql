@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    id         UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    family_id  UUID        NOT NULL,
+    parent_id  UUID REFERENCES refresh_tokens (id) ON DELETE SET NULL,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    session_id UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    token_hash TEXT        NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
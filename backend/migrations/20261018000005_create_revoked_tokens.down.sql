@@ -0,0 +1,5 @@
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS access_token_id,
+    DROP COLUMN IF EXISTS access_expires_at;
+
+DROP TABLE IF EXISTS revoked_tokens;
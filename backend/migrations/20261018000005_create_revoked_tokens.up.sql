@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS revoked_tokens
+(
+    jti        TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS access_token_id   TEXT,
+    ADD COLUMN IF NOT EXISTS access_expires_at TIMESTAMPTZ;
@@ -0,0 +1,22 @@
+// Package migrations holds the versioned schema migrations. SQL migrations are
+// embedded from this directory; Go migrations register themselves from files
+// named like the SQL ones, e.g. 20261018000005_backfill_users.go.
+package migrations
+
+import (
+	"embed"
+
+	"github.com/uptrace/bun/migrate"
+)
+
+//go:embed *.sql
+var sqlMigrations embed.FS
+
+// Migrations is every migration known to the binary
+var Migrations = migrate.NewMigrations()
+
+func init() {
+	if err := Migrations.Discover(sqlMigrations); err != nil {
+		panic(err)
+	}
+}
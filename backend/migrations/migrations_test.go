@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationsHaveUpAndDown(t *testing.T) {
+	sorted := Migrations.Sorted()
+	require.NotEmpty(t, sorted)
+
+	for _, m := range sorted {
+		assert.NotNil(t, m.Up, "%s has no up migration", m)
+		assert.NotNil(t, m.Down, "%s has no down migration", m)
+	}
+}
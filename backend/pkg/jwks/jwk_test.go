@@ -0,0 +1,67 @@
+package jwks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "Ed25519",
+			doc:     `{"keys":[{"kty":"OKP","kid":"a","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`,
+			wantLen: 1,
+		},
+		{
+			name:    "SkipsEncryptionKeys",
+			doc:     `{"keys":[{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"}]}`,
+			wantLen: 0,
+		},
+		{
+			name:    "UnsupportedKeyType",
+			doc:     `{"keys":[{"kty":"oct","kid":"a","k":"c2VjcmV0"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "PointNotOnCurve",
+			doc:     `{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE","y":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "InvalidJSON",
+			doc:     `{"keys":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := Parse([]byte(tt.doc))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLen, set.Len())
+		})
+	}
+}
+
+func TestSetKey(t *testing.T) {
+	set, err := Parse([]byte(`{"keys":[{"kty":"OKP","kid":"a","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`))
+	require.NoError(t, err)
+
+	_, err = set.Key("a")
+	assert.NoError(t, err)
+	_, err = set.Key("")
+	assert.NoError(t, err, "single key set matches tokens without kid")
+	_, err = set.Key("b")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
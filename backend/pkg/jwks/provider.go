@@ -0,0 +1,229 @@
+package jwks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Provider resolves signing keys by key ID
+type Provider interface {
+	Key(ctx context.Context, kid string) (Key, error)
+}
+
+// FileProvider serves keys from a local JWKS file, re-reading it when an unknown kid is requested
+type FileProvider struct {
+	path string
+
+	mu  sync.RWMutex
+	set *Set
+	mod time.Time
+}
+
+// NewFileProvider loads the JWKS file at path
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *FileProvider) Key(ctx context.Context, kid string) (Key, error) {
+	p.mu.RLock()
+	key, err := p.set.Key(kid)
+	p.mu.RUnlock()
+	if !errors.Is(err, ErrKeyNotFound) {
+		return key, err
+	}
+
+	// Pick up rotated keys when the file has changed on disk
+	if err = p.reload(); err != nil {
+		return Key{}, err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.set.Key(kid)
+}
+
+func (p *FileProvider) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("jwks: stat %s: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.set != nil && !info.ModTime().After(p.mod) {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("jwks: read %s: %w", p.path, err)
+	}
+	set, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	p.set = set
+	p.mod = info.ModTime()
+	return nil
+}
+
+// fetchTimeout bounds a JWKS fetch, which is detached from the request that started it
+const fetchTimeout = 10 * time.Second
+
+// URLProvider fetches keys from a remote JWKS endpoint and caches them for ttl.
+// Fetches run outside the lock and are shared by concurrent callers. An expired
+// set keeps being served while it refreshes in the background, and a failed
+// fetch is not retried for minRefresh. An unknown kid forces a refresh, at most
+// once per minRefresh, to follow key rotation.
+type URLProvider struct {
+	url        string
+	ttl        time.Duration
+	minRefresh time.Duration
+	client     *http.Client
+	now        func() time.Time
+
+	mu          sync.Mutex
+	set         *Set
+	fetchedAt   time.Time // last successful fetch
+	attemptedAt time.Time // last fetch, successful or not
+	lastErr     error
+	lastForced  time.Time
+	inflight    *fetchCall
+}
+
+// fetchCall is a fetch in progress; done is closed once err is set
+type fetchCall struct {
+	done chan struct{}
+	err  error
+}
+
+func NewURLProvider(url string, ttl time.Duration, client *http.Client) *URLProvider {
+	if client == nil {
+		client = &http.Client{Timeout: fetchTimeout}
+	}
+	return &URLProvider{
+		url:        url,
+		ttl:        ttl,
+		minRefresh: 30 * time.Second,
+		client:     client,
+		now:        time.Now,
+	}
+}
+
+func (p *URLProvider) Key(ctx context.Context, kid string) (Key, error) {
+	p.mu.Lock()
+	now := p.now()
+	backoff := now.Sub(p.attemptedAt) < p.minRefresh
+	switch {
+	case p.set == nil && backoff && p.lastErr != nil:
+		err := p.lastErr
+		p.mu.Unlock()
+		return Key{}, err
+	case p.set == nil:
+		// Nothing to serve yet, so wait for the first set
+		call := p.startFetch()
+		p.mu.Unlock()
+		if err := wait(ctx, call); err != nil {
+			return Key{}, err
+		}
+		p.mu.Lock()
+	case now.Sub(p.fetchedAt) >= p.ttl && !backoff:
+		// Keep serving stale keys while they refresh, or if the endpoint is down
+		p.startFetch()
+	}
+	set := p.set
+	p.mu.Unlock()
+
+	key, err := set.Key(kid)
+	if !errors.Is(err, ErrKeyNotFound) {
+		return key, err
+	}
+
+	p.mu.Lock()
+	if now.Sub(p.lastForced) < p.minRefresh {
+		p.mu.Unlock()
+		return key, err
+	}
+	p.lastForced = now
+	call := p.startFetch()
+	p.mu.Unlock()
+
+	if err = wait(ctx, call); err != nil {
+		return Key{}, err
+	}
+	p.mu.Lock()
+	set = p.set
+	p.mu.Unlock()
+	return set.Key(kid)
+}
+
+// startFetch starts a fetch, or returns the one in flight. It must be called with p.mu held.
+func (p *URLProvider) startFetch() *fetchCall {
+	if p.inflight != nil {
+		return p.inflight
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	p.inflight = call
+	p.attemptedAt = p.now()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		set, err := p.fetch(ctx)
+
+		p.mu.Lock()
+		if err == nil {
+			p.set = set
+			p.fetchedAt = p.now()
+		}
+		p.lastErr = err
+		p.inflight = nil
+		p.mu.Unlock()
+
+		call.err = err
+		close(call.done)
+	}()
+	return call
+}
+
+// wait returns the result of call, or gives up when ctx ends, leaving the fetch running
+func wait(ctx context.Context, call *fetchCall) error {
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *URLProvider) fetch(ctx context.Context) (*Set, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: fetch %s: %w", p.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetch %s: unexpected status %d", p.url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("jwks: read %s: %w", p.url, err)
+	}
+	return Parse(data)
+}
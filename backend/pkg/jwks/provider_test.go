@@ -0,0 +1,180 @@
+package jwks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyX = "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+
+// jwksServer serves a set with the key IDs in kids, or fails while down is set
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+	mu      sync.Mutex
+	kids    []string
+	down    bool
+	delay   time.Duration
+}
+
+func newJWKSServer(t *testing.T, kids ...string) *jwksServer {
+	s := &jwksServer{kids: kids}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		kids, down, delay := s.kids, s.down, s.delay
+		s.mu.Unlock()
+		time.Sleep(delay)
+		if down {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		doc := `{"keys":[`
+		for i, kid := range kids {
+			if i > 0 {
+				doc += ","
+			}
+			doc += `{"kty":"OKP","kid":"` + kid + `","crv":"Ed25519","x":"` + testKeyX + `"}`
+		}
+		_, _ = w.Write([]byte(doc + "]}"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(down bool, kids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+	if kids != nil {
+		s.kids = kids
+	}
+}
+
+// testClock is a settable clock for URLProvider.now
+type testClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *testClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestProvider(url string) (*URLProvider, *testClock) {
+	clock := &testClock{t: time.Unix(1_700_000_000, 0)}
+	p := NewURLProvider(url, time.Hour, nil)
+	p.now = clock.now
+	return p, clock
+}
+
+// settle waits for a background fetch to finish
+func settle(p *URLProvider) {
+	p.mu.Lock()
+	call := p.inflight
+	p.mu.Unlock()
+	if call != nil {
+		<-call.done
+	}
+}
+
+func TestURLProviderSharesFetch(t *testing.T) {
+	srv := newJWKSServer(t, "a")
+	srv.delay = 50 * time.Millisecond
+	p, _ := newTestProvider(srv.URL)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.Key(context.Background(), "a")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), srv.fetches.Load())
+}
+
+func TestURLProviderServesStaleSetWhileDown(t *testing.T) {
+	srv := newJWKSServer(t, "a")
+	p, clock := newTestProvider(srv.URL)
+	_, err := p.Key(context.Background(), "a")
+	require.NoError(t, err)
+
+	srv.set(true)
+	clock.advance(2 * time.Hour)
+	_, err = p.Key(context.Background(), "a")
+	assert.NoError(t, err, "the expired set is served while it refreshes")
+	settle(p)
+
+	_, err = p.Key(context.Background(), "a")
+	assert.NoError(t, err)
+	settle(p)
+	assert.Equal(t, int32(2), srv.fetches.Load(), "a failed refresh is not retried before minRefresh")
+
+	srv.set(false, "b")
+	clock.advance(time.Minute)
+	_, err = p.Key(context.Background(), "a")
+	assert.NoError(t, err)
+	settle(p)
+	_, err = p.Key(context.Background(), "b")
+	assert.NoError(t, err, "the refreshed set is picked up")
+	assert.Equal(t, int32(3), srv.fetches.Load())
+}
+
+func TestURLProviderFirstFetchBacksOff(t *testing.T) {
+	srv := newJWKSServer(t, "a")
+	srv.set(true)
+	p, clock := newTestProvider(srv.URL)
+
+	_, err := p.Key(context.Background(), "a")
+	assert.Error(t, err)
+	_, err = p.Key(context.Background(), "a")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), srv.fetches.Load())
+
+	srv.set(false)
+	clock.advance(time.Minute)
+	_, err = p.Key(context.Background(), "a")
+	assert.NoError(t, err)
+}
+
+func TestURLProviderUnknownKidForcesRefresh(t *testing.T) {
+	srv := newJWKSServer(t, "a")
+	p, clock := newTestProvider(srv.URL)
+	_, err := p.Key(context.Background(), "a")
+	require.NoError(t, err)
+
+	srv.set(false, "a", "rotated")
+	_, err = p.Key(context.Background(), "rotated")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), srv.fetches.Load())
+
+	_, err = p.Key(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = p.Key(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, int32(2), srv.fetches.Load(), "forced refreshes are rate limited")
+
+	clock.advance(time.Minute)
+	_, err = p.Key(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, int32(3), srv.fetches.Load())
+}
@@ -0,0 +1,25 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogSender writes messages to the logger instead of sending them. Intended for local development.
+type LogSender struct {
+	l *slog.Logger
+}
+
+func NewLogSender(l *slog.Logger) *LogSender {
+	return &LogSender{l: l}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.l.InfoContext(ctx, "email sent",
+		slog.String("from", msg.From),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
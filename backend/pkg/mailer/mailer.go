@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/rh-mithu/rizon/backend/config"
+)
+
+// Message is a plain-text email
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the Sender selected by cfg.MailDriver. The log and file drivers
+// expose the single-use login links, so they are refused in production.
+func New(cfg *config.Config, l *slog.Logger) (Sender, error) {
+	if cfg.Env == "production" && isDevDriver(cfg.MailDriver) {
+		return nil, fmt.Errorf("mail driver %q is for local development and cannot be used in production", cfg.MailDriver)
+	}
+	switch cfg.MailDriver {
+	case "", "log":
+		return NewLogSender(l), nil
+	case "file":
+		return NewFileSender(cfg.MailFilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.MailDriver)
+	}
+}
+
+func isDevDriver(driver string) bool {
+	switch driver {
+	case "", "log", "file":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/rh-mithu/rizon/backend/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		driver  string
+		want    Sender
+		wantErr bool
+	}{
+		{name: "DefaultLocal", env: "local", driver: "", want: &LogSender{}},
+		{name: "LogLocal", env: "local", driver: "log", want: &LogSender{}},
+		{name: "FileLocal", env: "local", driver: "file", want: &FileSender{}},
+		{name: "DefaultProduction", env: "production", driver: "", wantErr: true},
+		{name: "LogProduction", env: "production", driver: "log", wantErr: true},
+		{name: "FileProduction", env: "production", driver: "file", wantErr: true},
+		{name: "Unknown", env: "local", driver: "carrier-pigeon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Env: tt.env, MailDriver: tt.driver, MailFilePath: "mail.log"}
+			got, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, tt.want, got)
+		})
+	}
+}